@@ -0,0 +1,186 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/models"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client sends the requests to the JSON API of a snippetbox server
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// newClient returns a client for the server and the token of the config
+func newClient(cfg *config) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &client{
+		server: strings.TrimSuffix(cfg.Server, "/"),
+		token:  cfg.Token,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+// apiError is an error response of the API, FieldErrors holds the validation errors per field
+type apiError struct {
+	Status      int
+	Message     string            `json:"error"`
+	FieldErrors map[string]string `json:"errors"`
+}
+
+// Error implements the error interface, listing the field errors in a stable order
+func (e *apiError) Error() string {
+	messages := []string{}
+	if e.Message != "" {
+		messages = append(messages, e.Message)
+	}
+
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, e.FieldErrors[field]))
+	}
+
+	if len(messages) == 0 {
+		return http.StatusText(e.Status)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// do sends the request with the body encoded as JSON (if any) and decodes the response into dst (if any)
+func (c *client) do(method, path string, body any, dst any) error {
+	var reqBody io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequest(method, c.server+"/api/v1"+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rs, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rs.Body.Close()
+
+	if rs.StatusCode >= 400 {
+		e := &apiError{Status: rs.StatusCode}
+		// the body of the errors raised outside the API handlers is not JSON, the status text describes them
+		json.NewDecoder(rs.Body).Decode(e)
+		return e
+	}
+
+	if dst == nil || rs.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(rs.Body).Decode(dst)
+}
+
+// snippetInput is the body of the API request creating a snippet
+type snippetInput struct {
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Format     string   `json:"format,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Visibility string   `json:"visibility,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Expires    string   `json:"expires,omitempty"`
+}
+
+// createSnippet creates a snippet owned by the user of the token
+func (c *client) createSnippet(input snippetInput) (*models.Snippet, error) {
+	var rs struct {
+		Snippet *models.Snippet `json:"snippet"`
+	}
+
+	err := c.do(http.MethodPost, "/snippets", input, &rs)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.Snippet, nil
+}
+
+// getSnippet fetches a snippet, which burns a burn-after-reading snippet of another user
+func (c *client) getSnippet(id int) (*models.Snippet, error) {
+	var rs struct {
+		Snippet *models.Snippet `json:"snippet"`
+	}
+
+	err := c.do(http.MethodGet, "/snippets/"+strconv.Itoa(id), nil, &rs)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.Snippet, nil
+}
+
+// listSnippets fetches a page of the public snippets, or of the snippets of the user of the token if mine is true
+func (c *client) listSnippets(mine bool, page, size int) ([]*models.Snippet, models.Metadata, error) {
+	var rs struct {
+		Snippets []*models.Snippet `json:"snippets"`
+		Metadata models.Metadata   `json:"metadata"`
+	}
+
+	qs := url.Values{}
+	if mine {
+		qs.Set("mine", "true")
+	}
+	qs.Set("page", strconv.Itoa(page))
+	qs.Set("size", strconv.Itoa(size))
+
+	err := c.do(http.MethodGet, "/snippets?"+qs.Encode(), nil, &rs)
+	if err != nil {
+		return nil, models.Metadata{}, err
+	}
+
+	return rs.Snippets, rs.Metadata, nil
+}
+
+// deleteSnippet permanently deletes a snippet of the user of the token
+func (c *client) deleteSnippet(id int) error {
+	return c.do(http.MethodDelete, "/snippets/"+strconv.Itoa(id), nil, nil)
+}
+
+// shareURL returns the link for sharing the snippet in the browser
+func (c *client) shareURL(s *models.Snippet) string {
+	return c.server + "/s/" + s.Slug
+}
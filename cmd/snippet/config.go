@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer is the address of the server when the config file does not set one
+const defaultServer = "https://localhost:4000"
+
+// config holds the settings of the client, saved as JSON in the config file.
+// Insecure skips the verification of the TLS certificate, for the self-signed development certificate
+type config struct {
+	Server   string `json:"server"`
+	Token    string `json:"token,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+}
+
+// defaultConfigPath returns the path of the config file in the user's configuration directory
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "snippetbox", "config.json"), nil
+}
+
+// loadConfig reads the config file, a missing file gives the default settings
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Server: defaultServer}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// save writes the config file, readable by the user only as it holds the token
+func (cfg *config) save(path string) error {
+	data, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
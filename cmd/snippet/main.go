@@ -0,0 +1,307 @@
+// Command snippet is a command-line client for the JSON API of a snippetbox server
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const usage = `Usage: snippet [--config path] <command> [flags] [arguments]
+
+Commands:
+  configure --server URL --token TOKEN [--insecure]   save the server and the API token
+  create [flags] < file                               create a snippet from the standard input, print its link
+  get [--json] <id>                                   print the content of a snippet
+  list [--mine] [--page n] [--size n]                 print the ID, the slug and the title of the snippets
+  delete <id>                                         delete a snippet
+
+Run "snippet <command> --help" for the flags of a command.
+
+Global flags:
+`
+
+// errUsage is returned for the invalid command lines, after the usage has been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "snippet: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command line, reading the snippet content from stdin and writing the results to stdout,
+// so that it can be piped, and the usage to stderr
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("snippet", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	configPath := global.String("config", "", "path to the config file (default: snippetbox/config.json in the user's config directory)")
+	global.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		global.PrintDefaults()
+	}
+
+	err := global.Parse(args)
+	if err != nil {
+		return parseError(err)
+	}
+
+	if global.NArg() == 0 {
+		global.Usage()
+		return errUsage
+	}
+
+	if *configPath == "" {
+		*configPath, err = defaultConfigPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *configPath, err)
+	}
+
+	command, args := global.Arg(0), global.Args()[1:]
+	if command == "configure" {
+		return runConfigure(cfg, *configPath, args, stdout, stderr)
+	}
+
+	// the token in the environment takes precedence, so that it does not have to be saved on shared machines
+	if token := os.Getenv("SNIPPETBOX_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+
+	switch command {
+	case "create":
+		return runCreate(newClient(cfg), args, stdin, stdout, stderr)
+	case "get":
+		return runGet(newClient(cfg), args, stdout, stderr)
+	case "list":
+		return runList(newClient(cfg), args, stdout, stderr)
+	case "delete":
+		return runDelete(newClient(cfg), args, stderr)
+	default:
+		fmt.Fprintf(stderr, "snippet: unknown command %q\n", command)
+		global.Usage()
+		return errUsage
+	}
+}
+
+// parseError turns the errors of flag.FlagSet.Parse, which has already printed them along with the usage,
+// into errUsage. Asking for help is not an error
+func parseError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return errUsage
+}
+
+// parseArgs parses the flags of a command, which may come before, after or between its positional arguments,
+// and returns the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// readID parses the single snippet ID argument of a command
+func readID(fs *flag.FlagSet, args []string) (int, error) {
+	if len(args) != 1 {
+		fmt.Fprintf(fs.Output(), "snippet %s: expected exactly one snippet ID\n", fs.Name())
+		fs.Usage()
+		return 0, errUsage
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		fmt.Fprintf(fs.Output(), "snippet %s: invalid snippet ID %q\n", fs.Name(), args[0])
+		return 0, errUsage
+	}
+
+	return id, nil
+}
+
+// runConfigure saves the server address and the API token into the config file
+func runConfigure(cfg *config, path string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("configure", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.Server, "server", cfg.Server, "address of the snippetbox server")
+	fs.StringVar(&cfg.Token, "token", cfg.Token, "personal API token, created on the API Tokens page of your account")
+	fs.BoolVar(&cfg.Insecure, "insecure", cfg.Insecure, "skip the verification of the server's TLS certificate")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	err = cfg.save(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, path)
+	return nil
+}
+
+// runCreate creates a snippet with the content read from stdin and prints its link
+func runCreate(c *client, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	title := fs.String("title", "Untitled", "title of the snippet")
+	lang := fs.String("lang", "", "language of the content for the syntax highlighting, such as go")
+	format := fs.String("format", "", "plain, markdown or code (default: code with --lang, plain otherwise)")
+	visibility := fs.String("visibility", "public", "public, unlisted or private")
+	tags := fs.String("tags", "", "comma separated tags")
+	expires := fs.String("expires", "365d", `"never", a duration such as 30m, 12h or 7d, or an RFC 3339 date and time`)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	if len(positional) > 0 {
+		fmt.Fprintln(stderr, "snippet create: the content is read from the standard input")
+		fs.Usage()
+		return errUsage
+	}
+
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+
+	input := snippetInput{
+		Title:      *title,
+		Content:    string(content),
+		Format:     *format,
+		Language:   *lang,
+		Visibility: *visibility,
+		Expires:    *expires,
+	}
+	if input.Format == "" && input.Language != "" {
+		input.Format = "code"
+	}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			input.Tags = append(input.Tags, tag)
+		}
+	}
+
+	snippet, err := c.createSnippet(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, c.shareURL(snippet))
+	return nil
+}
+
+// runGet prints the content of a snippet as it is, or the whole snippet as JSON with --json
+func runGet(c *client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print the whole snippet as JSON")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+
+	id, err := readID(fs, positional)
+	if err != nil {
+		return err
+	}
+
+	snippet, err := c.getSnippet(id)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(stdout, snippet)
+	}
+
+	_, err = io.WriteString(stdout, snippet.Content)
+	return err
+}
+
+// runList prints a page of the snippets, one tab separated line of the ID, the slug and the title per snippet
+func runList(c *client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mine := fs.Bool("mine", false, "list your own snippets instead of the public ones")
+	page := fs.Int("page", 1, "page number")
+	size := fs.Int("size", 20, "number of snippets per page, at most 100")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	snippets, _, err := c.listSnippets(*mine, *page, *size)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range snippets {
+		fmt.Fprintf(stdout, "%d\t%s\t%s\n", s.ID, s.Slug, s.Title)
+	}
+
+	return nil
+}
+
+// runDelete deletes a snippet, printing nothing on success
+func runDelete(c *client, args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return parseError(err)
+	}
+
+	id, err := readID(fs, positional)
+	if err != nil {
+		return err
+	}
+
+	return c.deleteSnippet(id)
+}
+
+// writeJSON prints the value as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
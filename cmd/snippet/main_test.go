@@ -0,0 +1,244 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"asniki/snippetbox/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAPI is a stand-in for the JSON API of the server, which records the body of the last request
+type fakeAPI struct {
+	lastBody map[string]any
+}
+
+// respondJSON sends the data as a JSON response with the given status code
+func respondJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// routes returns the handler of the fake API, which accepts the "ValidToken" bearer token only
+func (api *fakeAPI) routes() http.Handler {
+	snippet := &models.Snippet{
+		ID:      1,
+		Slug:    "AnOldSilent1",
+		Title:   "An old silent pond",
+		Content: "An old silent pond...\n",
+		Created: time.Now(),
+		Owner:   models.User{ID: 1, Name: "Bob"},
+	}
+
+	authenticated := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer ValidToken" {
+				respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "you must be authenticated to access this resource"})
+				return
+			}
+			next(w, r)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/snippets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "1" {
+			respondJSON(w, http.StatusNotFound, map[string]string{"error": "the requested resource could not be found"})
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"snippet": snippet})
+	})
+	mux.HandleFunc("GET /api/v1/snippets", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("mine") == "true" {
+			authenticated(func(w http.ResponseWriter, r *http.Request) {
+				respondJSON(w, http.StatusOK, map[string]any{"snippets": []*models.Snippet{snippet}, "metadata": models.Metadata{}})
+			})(w, r)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"snippets": []*models.Snippet{}, "metadata": models.Metadata{}})
+	})
+	mux.HandleFunc("POST /api/v1/snippets", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		api.lastBody = map[string]any{}
+		json.NewDecoder(r.Body).Decode(&api.lastBody)
+
+		if api.lastBody["expires"] == "7w" {
+			respondJSON(w, http.StatusUnprocessableEntity, map[string]any{"errors": map[string]string{
+				"expires": "This field must be a valid date and time",
+				"content": "This field cannot be blank",
+			}})
+			return
+		}
+		respondJSON(w, http.StatusCreated, map[string]any{"snippet": &models.Snippet{ID: 2, Slug: "NewSnippet02"}})
+	}))
+	mux.HandleFunc("DELETE /api/v1/snippets/{id}", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return mux
+}
+
+// runCLI runs the command line against the test server with a config file holding the token,
+// and returns its stdout, stderr and error
+func runCLI(t *testing.T, serverURL, token string, stdin string, args ...string) (string, string, error) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := &config{Server: serverURL, Token: token}
+	err := cfg.save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = run(append([]string{"--config", path}, args...), strings.NewReader(stdin), &stdout, &stderr)
+
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("SNIPPETBOX_TOKEN", "")
+
+	api := &fakeAPI{}
+	ts := httptest.NewServer(api.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		stdin      string
+		args       []string
+		wantErr    string
+		wantStdout string
+	}{
+		{
+			name:       "Create",
+			token:      "ValidToken",
+			stdin:      "package main\n",
+			args:       []string{"create", "--expires", "7d", "--lang", "go", "--tags", "go, cli"},
+			wantStdout: ts.URL + "/s/NewSnippet02\n",
+		},
+		{
+			name:    "Create with an invalid expiry",
+			token:   "ValidToken",
+			stdin:   "",
+			args:    []string{"create", "--expires", "7w"},
+			wantErr: "content: This field cannot be blank; expires: This field must be a valid date and time",
+		},
+		{
+			name:    "Create without a token",
+			stdin:   "package main\n",
+			args:    []string{"create"},
+			wantErr: "you must be authenticated to access this resource",
+		},
+		{
+			name:    "Create with an argument",
+			token:   "ValidToken",
+			args:    []string{"create", "main.go"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:       "Get",
+			args:       []string{"get", "1"},
+			wantStdout: "An old silent pond...\n",
+		},
+		{
+			name:       "Get as JSON",
+			args:       []string{"get", "1", "--json"},
+			wantStdout: `"slug": "AnOldSilent1"`,
+		},
+		{
+			name:    "Get a missing snippet",
+			args:    []string{"get", "2"},
+			wantErr: "the requested resource could not be found",
+		},
+		{
+			name:    "Get an invalid ID",
+			args:    []string{"get", "foo"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:       "List own snippets",
+			token:      "ValidToken",
+			args:       []string{"list", "--mine"},
+			wantStdout: "1\tAnOldSilent1\tAn old silent pond\n",
+		},
+		{
+			name:    "List own snippets without a token",
+			args:    []string{"list", "--mine"},
+			wantErr: "you must be authenticated to access this resource",
+		},
+		{
+			name:  "Delete",
+			token: "ValidToken",
+			args:  []string{"delete", "1"},
+		},
+		{
+			name:    "Delete without an ID",
+			token:   "ValidToken",
+			args:    []string{"delete"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:    "Unknown command",
+			args:    []string{"publish"},
+			wantErr: errUsage.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, err := runCLI(t, ts.URL, tt.token, tt.stdin, tt.args...)
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else if err == nil {
+				t.Fatalf("got no error; want %q", tt.wantErr)
+			} else {
+				assert.Equal(t, err.Error(), tt.wantErr)
+			}
+
+			if strings.HasSuffix(tt.wantStdout, "\n") {
+				assert.Equal(t, stdout, tt.wantStdout)
+			} else {
+				assert.StringContains(t, stdout, tt.wantStdout)
+			}
+		})
+	}
+
+	t.Run("Create request", func(t *testing.T) {
+		_, _, _ = runCLI(t, ts.URL, "ValidToken", "package main\n", "create", "--title", "Main", "--lang", "go", "--expires", "never")
+
+		assert.Equal(t, api.lastBody["title"], any("Main"))
+		assert.Equal(t, api.lastBody["content"], any("package main\n"))
+		assert.Equal(t, api.lastBody["format"], any("code"))
+		assert.Equal(t, api.lastBody["language"], any("go"))
+		assert.Equal(t, api.lastBody["expires"], any("never"))
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippetbox", "config.json")
+
+	var stdout bytes.Buffer
+	err := run([]string{"--config", path, "configure", "--server", "https://snippets.example.com", "--token", "ValidToken"}, strings.NewReader(""), &stdout, io.Discard)
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), path+"\n")
+
+	cfg, err := loadConfig(path)
+	assert.NilError(t, err)
+	assert.Equal(t, *cfg, config{Server: "https://snippets.example.com", Token: "ValidToken"})
+}
+
+func TestAPIError(t *testing.T) {
+	err := error(&apiError{Status: http.StatusBadGateway})
+
+	var e *apiError
+	assert.Equal(t, errors.As(err, &e), true)
+	assert.Equal(t, err.Error(), "Bad Gateway")
+}
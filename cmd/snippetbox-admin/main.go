@@ -0,0 +1,203 @@
+// Command snippetbox-admin runs the maintenance tasks on the snippetbox database: creating users,
+// resetting their passwords, disabling their accounts, listing and deleting snippets and printing statistics
+package main
+
+import (
+	"asniki/snippetbox/internal/models"
+	"asniki/snippetbox/internal/validator"
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	// import is needed for the driver’s init() function to run so that it can register itself with the database/sql package
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: snippetbox-admin [-dsn DSN] <command> [flags]
+
+Commands:
+  user create --name NAME --email EMAIL               create a user, the password is read from the standard input
+  user reset-password --email EMAIL                   set a new password, read from the standard input
+  user disable --email EMAIL                          disable an account, ending its sessions and API tokens
+  user enable --email EMAIL                           enable a disabled account again
+  snippets list [--user EMAIL] [--from D] [--to D]    print the snippets of a user or created in a date range
+  snippets delete [--user EMAIL] [--from D] [--to D]  delete the snippets of a user or created in a date range
+  stats                                               print the number of the users and of the snippets
+
+Run "snippetbox-admin <command> --help" for the flags of a command.
+
+Global flags:
+`
+
+// errUsage is returned for the invalid command lines, after the usage has been printed
+var errUsage = errors.New("invalid usage")
+
+// admin holds the dependencies of the commands, the models are interfaces so that the tests can mock them
+type admin struct {
+	users    models.UserModelInterface
+	snippets models.SnippetModelInterface
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+// openDB initializes DB connection pool and check connection for errors
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func main() {
+	// unlike the web application, the .env file is optional as the DSN can be passed with -dsn
+	_ = godotenv.Load()
+
+	var dsn string
+	flag.StringVar(&dsn, "dsn", os.Getenv("DSN"), "MySQL data source name")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snippetbox-admin: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	app := &admin{
+		users:    &models.UserModel{DB: db},
+		snippets: &models.SnippetModel{DB: db},
+		stdin:    os.Stdin,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+	}
+
+	err = app.run(flag.Args())
+	if err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "snippetbox-admin: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command, writing the results to stdout and the usage to stderr
+func (app *admin) run(args []string) error {
+	if len(args) == 0 {
+		return app.usageError("")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "user", "snippets":
+		if len(args) == 0 {
+			return app.usageError(fmt.Sprintf("missing %s command", command))
+		}
+		command, args = command+" "+args[0], args[1:]
+	}
+
+	switch command {
+	case "user create":
+		return app.userCreate(args)
+	case "user reset-password":
+		return app.userResetPassword(args)
+	case "user disable":
+		return app.userSetDisabled(args, true)
+	case "user enable":
+		return app.userSetDisabled(args, false)
+	case "snippets list":
+		return app.snippetsList(args)
+	case "snippets delete":
+		return app.snippetsDelete(args)
+	case "stats":
+		return app.stats(args)
+	default:
+		return app.usageError(fmt.Sprintf("unknown command %q", command))
+	}
+}
+
+// usageError prints the message (if any) and the list of the commands, and returns errUsage
+func (app *admin) usageError(message string) error {
+	if message != "" {
+		fmt.Fprintf(app.stderr, "snippetbox-admin: %s\n", message)
+	}
+	fmt.Fprint(app.stderr, usage)
+	return errUsage
+}
+
+// newFlagSet returns the flag set of a command, which prints its errors and usage to stderr
+func (app *admin) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(app.stderr)
+	return fs
+}
+
+// parseFlags parses the flags of a command, which takes no positional arguments. Asking for help is not an error,
+// but stops the command: the returned bool is false when the command must not run
+func parseFlags(fs *flag.FlagSet, args []string) (bool, error) {
+	err := fs.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, nil
+		}
+		return false, errUsage
+	}
+
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "snippetbox-admin %s: unexpected argument %q\n", fs.Name(), fs.Arg(0))
+		fs.Usage()
+		return false, errUsage
+	}
+
+	return true, nil
+}
+
+// readPassword reads the password from the first line of stdin, so that it does not show up in the process list
+// or in the shell history
+func (app *admin) readPassword() (string, error) {
+	line, err := bufio.NewReader(app.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// validationError turns the failed checks of the validator into an error listing them in a stable order
+func validationError(v validator.Validator) error {
+	messages := slices.Clone(v.NonFieldErrors)
+
+	fields := make([]string, 0, len(v.FieldErrors))
+	for field := range v.FieldErrors {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, v.FieldErrors[field]))
+	}
+
+	return errors.New(strings.Join(messages, "; "))
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"asniki/snippetbox/internal/models/mocks"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// runAdmin runs the command against the mocked models and returns its stdout and error
+func runAdmin(t *testing.T, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+
+	app := &admin{
+		users:    &mocks.UserModel{},
+		snippets: &mocks.SnippetModel{},
+		stdin:    strings.NewReader(stdin),
+		stdout:   &stdout,
+		stderr:   io.Discard,
+	}
+
+	err := app.run(args)
+	return stdout.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		stdin      string
+		args       []string
+		wantErr    string
+		wantStdout string
+	}{
+		{
+			name:       "Create a user",
+			stdin:      "validPa$$word\n",
+			args:       []string{"user", "create", "--name", "Dave", "--email", "dave@example.com"},
+			wantStdout: "Created user dave@example.com\n",
+		},
+		{
+			name:    "Create a user with a duplicate email",
+			stdin:   "validPa$$word\n",
+			args:    []string{"user", "create", "--name", "Dave", "--email", "dupe@example.com"},
+			wantErr: `the email address "dupe@example.com" is already in use`,
+		},
+		{
+			name:    "Create an invalid user",
+			stdin:   "pa$$\n",
+			args:    []string{"user", "create", "--email", "dave@example."},
+			wantErr: "email: This field must be a valid email address; name: This field cannot be blank; password: This field must be at least 8 characters long",
+		},
+		{
+			name:       "Reset a password",
+			stdin:      "newPa$$word\n",
+			args:       []string{"user", "reset-password", "--email", "bob@example.com"},
+			wantStdout: "Reset the password of bob@example.com\n",
+		},
+		{
+			name:    "Reset the password of a missing user",
+			stdin:   "newPa$$word\n",
+			args:    []string{"user", "reset-password", "--email", "dave@example.com"},
+			wantErr: `no user with the email address "dave@example.com"`,
+		},
+		{
+			name:       "Disable an account",
+			args:       []string{"user", "disable", "--email", "bob@example.com"},
+			wantStdout: "Disabled the account of bob@example.com\n",
+		},
+		{
+			name:       "Enable an account",
+			args:       []string{"user", "enable", "--email", "carol@example.com"},
+			wantStdout: "Enabled the account of carol@example.com\n",
+		},
+		{
+			name:    "Disable without an email",
+			args:    []string{"user", "disable"},
+			wantErr: "the --email flag is required",
+		},
+		{
+			name:       "List the snippets of a user",
+			args:       []string{"snippets", "list", "--user", "bob@example.com"},
+			wantStdout: "1\tAnOldSilent1\t",
+		},
+		{
+			name:       "List the snippets created in a date range",
+			args:       []string{"snippets", "list", "--from", "2000-01-01", "--to", "2999-12-31"},
+			wantStdout: "3\tWintryForest\t",
+		},
+		{
+			name:    "List with an invalid date",
+			args:    []string{"snippets", "list", "--from", "01/01/2000"},
+			wantErr: `invalid date "01/01/2000", expected YYYY-MM-DD or an RFC 3339 date and time`,
+		},
+		{
+			name:    "List with an empty date range",
+			args:    []string{"snippets", "list", "--from", "2000-01-02", "--to", "2000-01-01T00:00:00Z"},
+			wantErr: "the --from date must be before the --to date",
+		},
+		{
+			name:       "Delete the snippets created since a date",
+			args:       []string{"snippets", "delete", "--from", "2000-01-01"},
+			wantStdout: "Deleted 2 snippet(s)\n",
+		},
+		{
+			name:       "Delete dry run",
+			args:       []string{"snippets", "delete", "--user", "bob@example.com", "--dry-run"},
+			wantStdout: "Would delete 1 snippet(s)\n",
+		},
+		{
+			name:    "Delete without a filter",
+			args:    []string{"snippets", "delete"},
+			wantErr: "at least one of the --user, --from and --to flags is required",
+		},
+		{
+			name: "Stats",
+			args: []string{"stats"},
+			wantStdout: "Users:                 3\n" +
+				"  disabled:            1\n" +
+				"Snippets:              7\n" +
+				"  expired:             0\n" +
+				"  public:              2\n" +
+				"  unlisted:            4\n" +
+				"  private:             1\n" +
+				"  burn after reading:  1\n" +
+				"  protected:           1\n" +
+				"  encrypted:           1\n",
+		},
+		{
+			name:    "Missing user command",
+			args:    []string{"user"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:    "Unexpected argument",
+			args:    []string{"stats", "users"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			name:    "Unknown command",
+			args:    []string{"snippets", "purge"},
+			wantErr: errUsage.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, err := runAdmin(t, tt.stdin, tt.args...)
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else if err == nil {
+				t.Fatalf("got no error; want %q", tt.wantErr)
+			} else {
+				assert.Equal(t, err.Error(), tt.wantErr)
+			}
+
+			if strings.HasSuffix(tt.wantStdout, "\n") {
+				assert.Equal(t, stdout, tt.wantStdout)
+			} else {
+				assert.StringContains(t, stdout, tt.wantStdout)
+			}
+		})
+	}
+}
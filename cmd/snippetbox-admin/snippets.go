@@ -0,0 +1,187 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/models"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// dateLayout is the layout of the dates accepted by the --from and --to flags, in UTC
+const dateLayout = "2006-01-02"
+
+// filterFlags holds the flags selecting the snippets
+type filterFlags struct {
+	user string
+	from string
+	to   string
+}
+
+// register adds the flags to the flag set
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.user, "user", "", "email address of the owner of the snippets")
+	fs.StringVar(&f.from, "from", "", "select the snippets created on or after this date (YYYY-MM-DD) or RFC 3339 time")
+	fs.StringVar(&f.to, "to", "", "select the snippets created on or before this date (YYYY-MM-DD), or before this RFC 3339 time")
+}
+
+// parseTime parses a date, which stands for its midnight in UTC, or an RFC 3339 date and time
+func parseTime(value string) (time.Time, bool, error) {
+	t, err := time.Parse(dateLayout, value)
+	if err == nil {
+		return t, true, nil
+	}
+
+	t, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or an RFC 3339 date and time", value)
+	}
+
+	return t, false, nil
+}
+
+// filter resolves the flags into a models.SnippetFilter. A --to date includes the whole day
+func (app *admin) filter(f filterFlags) (models.SnippetFilter, error) {
+	filter := models.SnippetFilter{}
+
+	if f.user != "" {
+		user, err := app.userByEmail(f.user)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = user.ID
+	}
+
+	if f.from != "" {
+		from, _, err := parseTime(f.from)
+		if err != nil {
+			return filter, err
+		}
+		filter.CreatedFrom = from
+	}
+
+	if f.to != "" {
+		to, isDate, err := parseTime(f.to)
+		if err != nil {
+			return filter, err
+		}
+		if isDate {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = to
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return filter, errors.New("the --from date must be before the --to date")
+	}
+
+	return filter, nil
+}
+
+// snippetsList prints the selected snippets, one tab separated line of the ID, the slug, the creation time,
+// the owner, the visibility and the title per snippet
+func (app *admin) snippetsList(args []string) error {
+	var f filterFlags
+
+	fs := app.newFlagSet("snippets list")
+	f.register(fs)
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	filter, err := app.filter(f)
+	if err != nil {
+		return err
+	}
+
+	snippets, err := app.snippets.Find(filter)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range snippets {
+		fmt.Fprintf(app.stdout, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			s.ID, s.Slug, s.Created.UTC().Format(time.RFC3339), s.Owner.Email, s.Visibility, s.Title)
+	}
+
+	return nil
+}
+
+// snippetsDelete deletes the selected snippets together with their revisions and files. At least one
+// of the flags is required, so that a mistyped command line cannot delete every snippet
+func (app *admin) snippetsDelete(args []string) error {
+	var f filterFlags
+
+	fs := app.newFlagSet("snippets delete")
+	f.register(fs)
+	dryRun := fs.Bool("dry-run", false, "print the number of the selected snippets without deleting them")
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	filter, err := app.filter(f)
+	if err != nil {
+		return err
+	}
+
+	if filter.IsZero() {
+		return errors.New("at least one of the --user, --from and --to flags is required")
+	}
+
+	if *dryRun {
+		snippets, err := app.snippets.Find(filter)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(app.stdout, "Would delete %d snippet(s)\n", len(snippets))
+		return nil
+	}
+
+	n, err := app.snippets.DeleteWhere(filter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stdout, "Deleted %d snippet(s)\n", n)
+	return nil
+}
+
+// stats prints the number of the users and of the snippets
+func (app *admin) stats(args []string) error {
+	fs := app.newFlagSet("stats")
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	users, err := app.users.Stats()
+	if err != nil {
+		return err
+	}
+
+	snippets, err := app.snippets.Stats()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(app.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Users:\t%d\n", users.Total)
+	fmt.Fprintf(tw, "  disabled:\t%d\n", users.Disabled)
+	fmt.Fprintf(tw, "Snippets:\t%d\n", snippets.Total)
+	fmt.Fprintf(tw, "  expired:\t%d\n", snippets.Expired)
+	fmt.Fprintf(tw, "  public:\t%d\n", snippets.Public)
+	fmt.Fprintf(tw, "  unlisted:\t%d\n", snippets.Unlisted)
+	fmt.Fprintf(tw, "  private:\t%d\n", snippets.Private)
+	fmt.Fprintf(tw, "  burn after reading:\t%d\n", snippets.BurnAfterReading)
+	fmt.Fprintf(tw, "  protected:\t%d\n", snippets.Protected)
+	fmt.Fprintf(tw, "  encrypted:\t%d\n", snippets.Encrypted)
+
+	return tw.Flush()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/models"
+	"asniki/snippetbox/internal/validator"
+	"errors"
+	"fmt"
+)
+
+// userCreateForm holds the fields of a new user, checked with the same rules as the signup form
+type userCreateForm struct {
+	Name     string
+	Email    string
+	Password string
+	validator.Validator
+}
+
+// validate checks the fields of the new user
+func (form *userCreateForm) validate() {
+	form.CheckField(
+		validator.NotBlank(form.Name),
+		"name",
+		"This field cannot be blank")
+	form.CheckField(
+		validator.NotBlank(form.Email),
+		"email",
+		"This field cannot be blank")
+	form.CheckField(
+		validator.Matches(form.Email, validator.EmailRX),
+		"email",
+		"This field must be a valid email address")
+	checkPassword(&form.Validator, form.Password)
+}
+
+// checkPassword checks a new password with the same rules as the signup form
+func checkPassword(v *validator.Validator, password string) {
+	v.CheckField(
+		validator.NotBlank(password),
+		"password",
+		"This field cannot be blank")
+	v.CheckField(
+		validator.MinChars(password, 8),
+		"password",
+		"This field must be at least 8 characters long")
+}
+
+// userByEmail fetches the user with the email address, failing with a readable error when there is none
+func (app *admin) userByEmail(email string) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("the --email flag is required")
+	}
+
+	user, err := app.users.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, fmt.Errorf("no user with the email address %q", email)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// userCreate creates a user with the password read from stdin
+func (app *admin) userCreate(args []string) error {
+	form := userCreateForm{}
+
+	fs := app.newFlagSet("user create")
+	fs.StringVar(&form.Name, "name", "", "name of the user")
+	fs.StringVar(&form.Email, "email", "", "email address of the user, used to login")
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	form.Password, err = app.readPassword()
+	if err != nil {
+		return err
+	}
+
+	form.validate()
+	if !form.Valid() {
+		return validationError(form.Validator)
+	}
+
+	err = app.users.Insert(form.Name, form.Email, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrDuplicateEmail) {
+			return fmt.Errorf("the email address %q is already in use", form.Email)
+		}
+		return err
+	}
+
+	fmt.Fprintf(app.stdout, "Created user %s\n", form.Email)
+	return nil
+}
+
+// userResetPassword sets the password read from stdin for a user who cannot login anymore
+func (app *admin) userResetPassword(args []string) error {
+	fs := app.newFlagSet("user reset-password")
+	email := fs.String("email", "", "email address of the user")
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	user, err := app.userByEmail(*email)
+	if err != nil {
+		return err
+	}
+
+	password, err := app.readPassword()
+	if err != nil {
+		return err
+	}
+
+	v := validator.Validator{}
+	checkPassword(&v, password)
+	if !v.Valid() {
+		return validationError(v)
+	}
+
+	err = app.users.PasswordReset(user.ID, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stdout, "Reset the password of %s\n", user.Email)
+	return nil
+}
+
+// userSetDisabled disables or enables the account of a user
+func (app *admin) userSetDisabled(args []string, disabled bool) error {
+	name := "user enable"
+	if disabled {
+		name = "user disable"
+	}
+
+	fs := app.newFlagSet(name)
+	email := fs.String("email", "", "email address of the user")
+
+	ok, err := parseFlags(fs, args)
+	if !ok {
+		return err
+	}
+
+	user, err := app.userByEmail(*email)
+	if err != nil {
+		return err
+	}
+
+	err = app.users.SetDisabled(user.ID, disabled)
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		fmt.Fprintf(app.stdout, "Disabled the account of %s\n", user.Email)
+	} else {
+		fmt.Fprintf(app.stdout, "Enabled the account of %s\n", user.Email)
+	}
+	return nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/models"
+	"asniki/snippetbox/internal/validator"
+	"asniki/snippetbox/ui"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envelope wraps the JSON responses of the API in a named top-level object
+type envelope map[string]any
+
+// maxJSONBytes limits the size of the JSON request bodies
+const maxJSONBytes = 1_048_576
+
+// writeJSON sends the data as a JSON response with the given status code
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope) error {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+
+	return nil
+}
+
+// readJSON decodes a single JSON object from the request body to the target destination,
+// rejecting the unknown fields and the bodies over maxJSONBytes with a descriptive error
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+		var invalidUnmarshalError *json.InvalidUnmarshalError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown field %s", fieldName)
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		case errors.As(err, &invalidUnmarshalError):
+			panic(err)
+		default:
+			return err
+		}
+	}
+
+	err = dec.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// errorResponse sends a JSON error response with the given status code and message
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
+	err := app.writeJSON(w, status, envelope{"error": message})
+	if err != nil {
+		app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// serverErrorResponse logs the error and sends a generic 500 Internal Server Error JSON response
+func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+	app.errorResponse(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+// notFoundResponse sends a 404 Not Found JSON response
+func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusNotFound, "the requested resource could not be found")
+}
+
+// badRequestResponse sends a 400 Bad Request JSON response with the error message
+func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+}
+
+// failedValidationResponse sends a 422 Unprocessable Entity JSON response with the validation errors
+func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, v validator.Validator) {
+	data := envelope{"errors": v.FieldErrors}
+	if len(v.NonFieldErrors) > 0 {
+		data["error"] = strings.Join(v.NonFieldErrors, "; ")
+	}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, data)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// invalidTokenResponse sends a 401 Unauthorized JSON response for a malformed, unknown or expired token
+func (app *application) invalidTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.errorResponse(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+// authenticationRequiredResponse sends a 401 Unauthorized JSON response to an anonymous client
+func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.errorResponse(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+}
+
+// notPermittedResponse sends a 403 Forbidden JSON response with the reason
+func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request, message string) {
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+// apiSnippetFromPath fetches the live snippet identified by the {id} path value. It works like snippetFromPath,
+// but sends the error responses as JSON. The unlisted, burn-after-reading and protected snippets of other users
+// are reported as not found, unless they are legacy public snippets, they can only be reached through their share slug
+func (app *application) apiSnippetFromPath(w http.ResponseWriter, r *http.Request) *models.Snippet {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil
+	}
+
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil
+	}
+
+	if !snippet.VisibleByIDTo(app.authenticatedUserID(r)) {
+		app.notFoundResponse(w, r)
+		return nil
+	}
+
+	return snippet
+}
+
+// apiSnippetOwned fetches the snippet from the {id} path value and makes sure it belongs to the current user
+func (app *application) apiSnippetOwned(w http.ResponseWriter, r *http.Request) *models.Snippet {
+	snippet := app.apiSnippetFromPath(w, r)
+	if snippet == nil {
+		return nil
+	}
+
+	if snippet.Owner.ID != app.authenticatedUserID(r) {
+		app.notPermittedResponse(w, r, "you do not own this snippet")
+		return nil
+	}
+
+	return snippet
+}
+
+// apiOpenAPI sends the OpenAPI document describing the API
+func (app *application) apiOpenAPI(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, ui.Files, "api/openapi.json")
+}
+
+// apiSnippetList sends a page of the live public snippets, or of the snippets of the current user with mine=true
+func (app *application) apiSnippetList(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	page := app.readInt(qs, "page", 1, &v)
+	pageSize := app.readInt(qs, "size", 20, &v)
+	v.CheckField(page > 0, "page", "must be greater than zero")
+	v.CheckField(pageSize > 0 && pageSize <= 100, "size", "must be between 1 and 100")
+
+	var snippets []*models.Snippet
+	var metadata models.Metadata
+	var err error
+
+	if mine := qs.Get("mine"); mine == "true" {
+		if !app.isAuthenticated(r) {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		sort := qs.Get("sort")
+		if sort == "" {
+			sort = "-created"
+		}
+		v.CheckField(validator.PermittedValue(sort, models.SnippetSortValues...), "sort", "invalid sort value")
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+		snippets, metadata, err = app.snippets.ListByUser(app.authenticatedUserID(r), sort, page, pageSize)
+	} else {
+		beforeID := app.readInt(qs, "before", 0, &v)
+		afterID := app.readInt(qs, "after", 0, &v)
+		v.CheckField(beforeID >= 0, "before", "must not be negative")
+		v.CheckField(afterID >= 0, "after", "must not be negative")
+		v.CheckField(beforeID == 0 || afterID == 0, "after", "cannot be used together with before")
+		v.CheckField(mine == "" || mine == "false", "mine", "must be true or false")
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v)
+			return
+		}
+
+		snippets, metadata, err = app.snippets.Archive(beforeID, afterID, page, pageSize)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippets": snippets, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// apiSnippetGet sends a specific snippet. A burn-after-reading snippet is deleted as it is read by anyone
+// other than its owner, and a password protected one is only sent to its owner
+func (app *application) apiSnippetGet(w http.ResponseWriter, r *http.Request) {
+	snippet := app.apiSnippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	isOwner := snippet.Owner.ID == app.authenticatedUserID(r)
+
+	if snippet.Protected && !isOwner {
+		app.notPermittedResponse(w, r, "this snippet is protected by a password, open it in the browser to unlock it")
+		return
+	}
+
+	if snippet.BurnAfterReading && !isOwner {
+		var err error
+		snippet, err = app.snippets.Burn(snippet.ID)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.notFoundResponse(w, r)
+			} else {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	if snippet.Visibility != "public" || snippet.BurnAfterReading {
+		w.Header().Add("Cache-Control", "no-store")
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"snippet": snippet})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// apiSnippetCreateInput represents the JSON body of the API request creating a snippet.
+// Expires is "never", a duration such as "30m", "12h" or "7d", or an RFC 3339 date and time
+type apiSnippetCreateInput struct {
+	Title            string            `json:"title"`
+	Content          string            `json:"content"`
+	Format           string            `json:"format"`
+	Language         string            `json:"language"`
+	Visibility       string            `json:"visibility"`
+	Tags             []string          `json:"tags"`
+	BurnAfterReading bool              `json:"burn_after_reading"`
+	Password         string            `json:"password"`
+	Encrypted        bool              `json:"encrypted"`
+	Expires          string            `json:"expires"`
+	Files            []snippetFileForm `json:"files"`
+}
+
+// apiExpiresInRX matches the "expires in" durations accepted by the API
+var apiExpiresInRX = regexp.MustCompile(`^(\d{1,9})([mhd])$`)
+
+// apiExpiresUnits maps the suffixes of the "expires in" durations to the units of the "snippet create" form
+var apiExpiresUnits = map[string]string{"m": "minutes", "h": "hours", "d": "days"}
+
+// form converts the input to the "snippet create" form, so both are checked by the same rules.
+// The omitted fields get the defaults of the form in the browser
+func (input apiSnippetCreateInput) form() snippetCreateForm {
+	form := snippetCreateForm{
+		Title:            input.Title,
+		Content:          input.Content,
+		Format:           input.Format,
+		Language:         input.Language,
+		Visibility:       input.Visibility,
+		Tags:             strings.Join(input.Tags, ","),
+		BurnAfterReading: input.BurnAfterReading,
+		Password:         input.Password,
+		Encrypted:        input.Encrypted,
+		Files:            input.Files,
+	}
+
+	if form.Format == "" {
+		form.Format = "plain"
+	}
+	if form.Language == "" {
+		form.Language = "plaintext"
+	}
+	if form.Visibility == "" {
+		form.Visibility = "public"
+	}
+	for i := range form.Files {
+		if form.Files[i].Language == "" {
+			form.Files[i].Language = "plaintext"
+		}
+	}
+
+	switch matches := apiExpiresInRX.FindStringSubmatch(input.Expires); {
+	case input.Expires == "":
+		form.Expires, form.ExpiresIn, form.ExpiresUnit = "in", 365, "days"
+	case input.Expires == "never":
+		form.Expires = "never"
+	case matches != nil:
+		form.Expires, form.ExpiresUnit = "in", apiExpiresUnits[matches[2]]
+		form.ExpiresIn, _ = strconv.Atoi(matches[1])
+	default:
+		form.Expires, form.ExpiresAt = "at", input.Expires
+		if t, err := time.Parse(time.RFC3339, input.Expires); err == nil {
+			form.ExpiresAt = t.UTC().Format(expiresAtLayouts[1])
+		}
+	}
+
+	return form
+}
+
+// apiSnippetCreate saves a new snippet owned by the current user
+func (app *application) apiSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	var input apiSnippetCreateInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	form := input.form()
+	snippet := form.snippet(time.Now().UTC())
+
+	if !form.Valid() {
+		app.failedValidationResponse(w, r, form.Validator)
+		return
+	}
+
+	snippet.Owner = models.User{ID: app.authenticatedUserID(r)}
+
+	_, err = app.snippets.Insert(snippet, form.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/snippets/%d", snippet.ID))
+	err = app.writeJSON(w, http.StatusCreated, envelope{"snippet": snippet})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// apiSnippetUpdateInput represents the JSON body of the API request updating a snippet,
+// the omitted fields are left unchanged
+type apiSnippetUpdateInput struct {
+	Title      *string   `json:"title"`
+	Content    *string   `json:"content"`
+	Format     *string   `json:"format"`
+	Language   *string   `json:"language"`
+	Visibility *string   `json:"visibility"`
+	Tags       *[]string `json:"tags"`
+}
+
+// apiSnippetUpdate saves the changes to an existing snippet of the current user
+func (app *application) apiSnippetUpdate(w http.ResponseWriter, r *http.Request) {
+	snippet := app.apiSnippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	if snippet.Encrypted {
+		app.notPermittedResponse(w, r, "encrypted snippets can only be changed in the browser holding the key")
+		return
+	}
+
+	var input apiSnippetUpdateInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	form := snippetCreateForm{
+		Title:      snippet.Title,
+		Content:    snippet.Content,
+		Format:     snippet.Format,
+		Language:   snippet.Language,
+		Visibility: snippet.Visibility,
+		Tags:       strings.Join(snippet.Tags, ","),
+	}
+	if input.Title != nil {
+		form.Title = *input.Title
+	}
+	if input.Content != nil {
+		form.Content = *input.Content
+	}
+	if input.Format != nil {
+		form.Format = *input.Format
+	}
+	if input.Language != nil {
+		form.Language = *input.Language
+	}
+	if input.Visibility != nil {
+		form.Visibility = *input.Visibility
+	}
+	if input.Tags != nil {
+		form.Tags = strings.Join(*input.Tags, ",")
+	}
+
+	form.validate()
+	form.checkFilename(snippet.Files)
+
+	if !form.Valid() {
+		app.failedValidationResponse(w, r, form.Validator)
+		return
+	}
+
+	snippet.Title = form.Title
+	snippet.Content = form.Content
+	snippet.Format = form.Format
+	snippet.Language = form.Language
+	snippet.Visibility = form.Visibility
+	snippet.Tags = parseTags(form.Tags)
+
+	err = app.snippets.Update(snippet, app.authenticatedUserID(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippet": snippet})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// apiSnippetDelete permanently deletes a snippet of the current user
+func (app *application) apiSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	snippet := app.apiSnippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"net/http"
+	"testing"
+)
+
+func TestAPISnippetGet(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Public snippet",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Private snippet of another user",
+			urlPath:  "/api/v1/snippets/4",
+			wantCode: http.StatusNotFound,
+			wantBody: "could not be found",
+		},
+		{
+			name:     "Private snippet with a read-only token",
+			urlPath:  "/api/v1/snippets/4",
+			token:    "ReadOnlyBobToken",
+			wantCode: http.StatusOK,
+			wantBody: `"visibility": "private"`,
+		},
+		{
+			name:     "Malformed authorization header",
+			urlPath:  "/api/v1/snippets/1",
+			token:    " ",
+			wantCode: http.StatusUnauthorized,
+			wantBody: "invalid or missing authentication token",
+		},
+		{
+			name:     "Private snippet of the token owner",
+			urlPath:  "/api/v1/snippets/4",
+			token:    "ValidBobToken",
+			wantCode: http.StatusOK,
+			wantBody: `"visibility": "private"`,
+		},
+		{
+			name:     "Protected snippet created before the share slugs",
+			urlPath:  "/api/v1/snippets/6",
+			wantCode: http.StatusForbidden,
+			wantBody: "protected by a password",
+		},
+		{
+			name:     "Burn after reading snippet",
+			urlPath:  "/api/v1/snippets/5",
+			wantCode: http.StatusNotFound,
+			wantBody: "could not be found",
+		},
+		{
+			name:     "Burn after reading snippet of the token owner",
+			urlPath:  "/api/v1/snippets/5",
+			token:    "ReadOnlyBobToken",
+			wantCode: http.StatusOK,
+			wantBody: "s3cr3t-t0k3n",
+		},
+		{
+			name:     "Unlisted snippet",
+			urlPath:  "/api/v1/snippets/7",
+			wantCode: http.StatusNotFound,
+			wantBody: "could not be found",
+		},
+		{
+			name:     "Unlisted snippet of the token owner",
+			urlPath:  "/api/v1/snippets/7",
+			token:    "ReadOnlyBobToken",
+			wantCode: http.StatusOK,
+			wantBody: `"visibility": "unlisted"`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			wantCode: http.StatusNotFound,
+			wantBody: "could not be found",
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/api/v1/snippets/foo",
+			wantCode: http.StatusNotFound,
+			wantBody: "could not be found",
+		},
+		{
+			name:     "Invalid token",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "InvalidToken",
+			wantCode: http.StatusUnauthorized,
+			wantBody: "invalid or missing authentication token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.doJSON(t, http.MethodGet, tt.urlPath, tt.token, "")
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetList(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Public snippets",
+			urlPath:  "/api/v1/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Own snippets",
+			urlPath:  "/api/v1/snippets?mine=true",
+			token:    "ValidBobToken",
+			wantCode: http.StatusOK,
+			wantBody: `"current_page": 1`,
+		},
+		{
+			name:     "Own snippets without a token",
+			urlPath:  "/api/v1/snippets?mine=true",
+			wantCode: http.StatusUnauthorized,
+			wantBody: "you must be authenticated",
+		},
+		{
+			name:     "Invalid page size",
+			urlPath:  "/api/v1/snippets?size=0",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"size": "must be between 1 and 100"`,
+		},
+		{
+			name:     "Invalid sort",
+			urlPath:  "/api/v1/snippets?mine=true&sort=owner",
+			token:    "ValidBobToken",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"sort": "invalid sort value"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.doJSON(t, http.MethodGet, tt.urlPath, tt.token, "")
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		token        string
+		body         string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			token:        "ValidBobToken",
+			body:         `{"title": "O snail", "content": "O snail\nClimb Mount Fuji", "tags": ["haiku"], "expires": "7d"}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/2",
+			wantBody:     `"slug": "NewSnippet02"`,
+		},
+		{
+			name:         "Never expiring snippet",
+			token:        "ValidBobToken",
+			body:         `{"title": "O snail", "content": "O snail", "expires": "never"}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/2",
+			wantBody:     `"expires": null`,
+		},
+		{
+			name:     "Without a token",
+			body:     `{"title": "O snail", "content": "O snail"}`,
+			wantCode: http.StatusUnauthorized,
+			wantBody: "you must be authenticated",
+		},
+		{
+			name:     "Read-only token",
+			token:    "ReadOnlyBobToken",
+			body:     `{"title": "O snail", "content": "O snail"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: `this token does not have the \"write\" scope`,
+		},
+		{
+			name:     "Blank title",
+			token:    "ValidBobToken",
+			body:     `{"title": "", "content": "O snail"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"title": "This field cannot be blank"`,
+		},
+		{
+			name:     "Invalid language",
+			token:    "ValidBobToken",
+			body:     `{"title": "O snail", "content": "O snail", "format": "code", "language": "klingon"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"language": "This field must be one of the supported languages"`,
+		},
+		{
+			name:     "Invalid expiry",
+			token:    "ValidBobToken",
+			body:     `{"title": "O snail", "content": "O snail", "expires": "7w"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires": "This field must be a valid date and time"`,
+		},
+		{
+			name:     "Expiry in the past",
+			token:    "ValidBobToken",
+			body:     `{"title": "O snail", "content": "O snail", "expires": "2020-01-02T15:04:05Z"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires": "This field must be in the future and at most 10 years from now"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.doJSON(t, http.MethodPost, "/api/v1/snippets", tt.token, tt.body)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetUpdate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid changes",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "ValidBobToken",
+			body:     `{"title": "A new silent pond"}`,
+			wantCode: http.StatusOK,
+			wantBody: `"title": "A new silent pond"`,
+		},
+		{
+			name:     "Snippet of another user",
+			urlPath:  "/api/v1/snippets/3",
+			token:    "ValidBobToken",
+			body:     `{"title": "A new silent pond"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: "you do not own this snippet",
+		},
+		{
+			name:     "Encrypted snippet",
+			urlPath:  "/api/v1/snippets/7",
+			token:    "ValidBobToken",
+			body:     `{"title": "A new silent pond"}`,
+			wantCode: http.StatusForbidden,
+			wantBody: "encrypted snippets",
+		},
+		{
+			name:     "Invalid visibility",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "ValidBobToken",
+			body:     `{"visibility": "everyone"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"visibility": "This field must equal public, unlisted or private"`,
+		},
+		{
+			name:     "Title of a file",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "ValidBobToken",
+			body:     `{"title": "Frog", "format": "code", "language": "go"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"title": "The snippet content would be downloaded as frog.go, which is the name of one of its files"`,
+		},
+		{
+			name:     "Field which cannot be changed",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "ValidBobToken",
+			body:     `{"expires": "never"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `unknown field \"expires\"`,
+		},
+		{
+			name:     "Without a token",
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title": "A new silent pond"}`,
+			wantCode: http.StatusUnauthorized,
+			wantBody: "you must be authenticated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.doJSON(t, http.MethodPatch, tt.urlPath, tt.token, tt.body)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "Own snippet",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "ValidBobToken",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Snippet of another user",
+			urlPath:  "/api/v1/snippets/3",
+			token:    "ValidBobToken",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			token:    "ValidBobToken",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Without a token",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.doJSON(t, http.MethodDelete, tt.urlPath, tt.token, "")
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
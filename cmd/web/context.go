@@ -3,4 +3,8 @@ package main
 // contextKey is a custom type for the context keys
 type contextKey string
 
-const isAuthenticatedContextKey = contextKey("isAuthenticated")
+const (
+	isAuthenticatedContextKey     = contextKey("isAuthenticated")
+	authenticatedUserIDContextKey = contextKey("authenticatedUserID")
+	tokenContextKey               = contextKey("token")
+)
@@ -5,18 +5,265 @@ import (
 	"asniki/snippetbox/internal/validator"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// supportedVisibilities lists who can find and view a snippet: everyone (public),
+// only those who have the link (unlisted) or only the owner (private)
+var supportedVisibilities = []string{"public", "unlisted", "private"}
+
 // snippetCreateForm represent the form data and validation errors for the "snippet create" form fields
 type snippetCreateForm struct {
-	Title               string `form:"title"`
-	Content             string `form:"content"`
-	Expires             int    `form:"expires"`
+	Title               string            `form:"title"`
+	Content             string            `form:"content"`
+	Format              string            `form:"format"`
+	Language            string            `form:"language"`
+	Visibility          string            `form:"visibility"`
+	Tags                string            `form:"tags"`
+	BurnAfterReading    bool              `form:"burn"`
+	Password            string            `form:"password"`
+	Encrypted           bool              `form:"encrypted"`
+	Expires             string            `form:"expires"`
+	ExpiresIn           int               `form:"expiresIn"`
+	ExpiresUnit         string            `form:"expiresUnit"`
+	ExpiresAt           string            `form:"expiresAt"`
+	Files               []snippetFileForm `form:"files"`
 	validator.Validator `form:"-"`
 }
 
+// snippetFileForm represent the form data of an additional file on the "snippet create" form
+type snippetFileForm struct {
+	Name     string `form:"name" json:"name"`
+	Language string `form:"language" json:"language"`
+	Content  string `form:"content" json:"content"`
+}
+
+// validate checks the fields shared by the "snippet create" and "snippet edit" forms
+func (form *snippetCreateForm) validate() {
+	form.CheckField(
+		validator.NotBlank(form.Title),
+		"title",
+		"This field cannot be blank")
+	form.CheckField(
+		validator.MaxChars(form.Title, 100),
+		"title",
+		"This field cannot be more than 100 characters long")
+	form.CheckField(
+		validator.NotBlank(form.Content),
+		"content",
+		"This field cannot be blank")
+	form.CheckField(
+		validator.PermittedValue(form.Format, supportedFormats...),
+		"format",
+		"This field must equal plain, markdown or code")
+	form.CheckField(
+		validator.PermittedValue(form.Language, supportedLanguages...),
+		"language",
+		"This field must be one of the supported languages")
+	form.CheckField(
+		validator.PermittedValue(form.Visibility, supportedVisibilities...),
+		"visibility",
+		"This field must equal public, unlisted or private")
+
+	tags := parseTags(form.Tags)
+	form.CheckField(
+		validator.MaxItems(tags, 10),
+		"tags",
+		"This field cannot contain more than 10 tags")
+	for _, tag := range tags {
+		form.CheckField(
+			validator.MaxChars(tag, 32) && validator.Matches(tag, validator.TagRX),
+			"tags",
+			"Tags can only contain letters, digits and single dashes and be up to 32 characters long")
+	}
+}
+
+// snippetRevealForm represent the form revealing a burn-after-reading snippet, which is posted back to the page it was shown on
+type snippetRevealForm struct {
+	Action string
+}
+
+// snippetUnlockForm represent the form data and validation errors for the "snippet unlock" form fields,
+// Next is the snippet page to return to once the snippet is unlocked
+type snippetUnlockForm struct {
+	Password            string `form:"password"`
+	Next                string `form:"next"`
+	validator.Validator `form:"-"`
+}
+
+// checkFiles checks the additional files of the "snippet create" form and returns them,
+// skipping the blank rows and the gaps in the numbering left by the files removed in the browser
+func (form *snippetCreateForm) checkFiles() []models.SnippetFile {
+	files := []models.SnippetFile{}
+	for _, f := range form.Files {
+		if strings.TrimSpace(f.Name) == "" && strings.TrimSpace(f.Content) == "" {
+			continue
+		}
+		files = append(files, models.SnippetFile{Name: strings.TrimSpace(f.Name), Language: f.Language, Content: f.Content})
+	}
+
+	form.CheckField(
+		validator.MaxItems(files, 10),
+		"files",
+		"A snippet cannot have more than 10 additional files")
+	form.CheckField(
+		len(files) == 0 || !form.Encrypted,
+		"files",
+		"Encrypted snippets cannot have additional files")
+
+	// the snippet content goes in the zip archive under its download name, which the files cannot take
+	names := []string{form.filename()}
+	for i, f := range files {
+		form.CheckField(
+			validator.MaxChars(f.Name, 100) && validator.Matches(f.Name, snippetFileRX),
+			"files",
+			fmt.Sprintf("File %d: the name can only contain letters, digits, dots, dashes and underscores", i+1))
+		form.CheckField(
+			!slices.Contains(names, f.Name),
+			"files",
+			fmt.Sprintf("File %d: the name is already used by another file or by the snippet content", i+1))
+		form.CheckField(
+			validator.NotBlank(f.Content),
+			"files",
+			fmt.Sprintf("File %d: the content cannot be blank", i+1))
+		form.CheckField(
+			validator.PermittedValue(f.Language, supportedLanguages...),
+			"files",
+			fmt.Sprintf("File %d: the language must be one of the supported languages", i+1))
+		names = append(names, f.Name)
+	}
+
+	return files
+}
+
+// filename returns the download file name of the snippet content described by the form
+func (form *snippetCreateForm) filename() string {
+	return snippetFilename(&models.Snippet{Title: form.Title, Format: form.Format, Language: form.Language})
+}
+
+// checkFilename checks that the edited title, format and language do not give the snippet content
+// the download name of one of the existing files of the snippet
+func (form *snippetCreateForm) checkFilename(files []models.SnippetFile) {
+	filename := form.filename()
+	form.CheckField(
+		!slices.ContainsFunc(files, func(f models.SnippetFile) bool { return f.Name == filename }),
+		"title",
+		fmt.Sprintf("The snippet content would be downloaded as %s, which is the name of one of its files", filename))
+}
+
+// expiryUnits maps the units of the "expires in" duration of a new snippet to their length
+var expiryUnits = map[string]time.Duration{
+	"minutes": time.Minute,
+	"hours":   time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// maxExpiry is the longest time a snippet can be kept for, unless it never expires
+const maxExpiry = 10 * 365 * 24 * time.Hour
+
+// expiresAtLayouts lists the layouts the browsers use for the value of a datetime-local input
+var expiresAtLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+// expiry checks the expiry fields of the "snippet create" form and returns the expiry time of the new snippet:
+// a duration from now (in), a UTC date and time (at) or the zero time if the snippet never expires (never)
+func (form *snippetCreateForm) expiry(now time.Time) time.Time {
+	var expires time.Time
+
+	switch form.Expires {
+	case "in":
+		unit, ok := expiryUnits[form.ExpiresUnit]
+		if !ok {
+			form.AddFieldError("expires", "The unit must equal minutes, hours or days")
+			return expires
+		}
+
+		form.CheckField(
+			form.ExpiresIn > 0 && form.ExpiresIn <= int(maxExpiry/unit),
+			"expires",
+			"This field must be a positive duration of at most 10 years")
+		expires = now.Add(time.Duration(form.ExpiresIn) * unit)
+	case "at":
+		var err error
+		for _, layout := range expiresAtLayouts {
+			expires, err = time.Parse(layout, form.ExpiresAt)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			form.AddFieldError("expires", "This field must be a valid date and time")
+			return time.Time{}
+		}
+
+		form.CheckField(
+			expires.After(now) && !expires.After(now.Add(maxExpiry)),
+			"expires",
+			"This field must be in the future and at most 10 years from now")
+	case "never":
+	default:
+		form.AddFieldError("expires", "This field must equal in, at or never")
+	}
+
+	return expires
+}
+
+// snippet checks the "snippet create" form and returns the new snippet without an owner,
+// which can only be saved if the form is valid
+func (form *snippetCreateForm) snippet(now time.Time) *models.Snippet {
+	form.validate()
+	expires := form.expiry(now)
+	files := form.checkFiles()
+	if form.Encrypted {
+		form.CheckField(
+			validator.Matches(form.Content, ciphertextRX),
+			"content",
+			"This field must be encrypted in the browser, please enable JavaScript")
+	}
+	if form.Password != "" {
+		form.CheckField(
+			validator.MinChars(form.Password, 8),
+			"password",
+			"This field must be at least 8 characters long")
+		form.CheckField(
+			len(form.Password) <= 72,
+			"password",
+			"This field cannot be more than 72 bytes long")
+	}
+
+	snippet := &models.Snippet{
+		Title:            form.Title,
+		Content:          form.Content,
+		Format:           form.Format,
+		Language:         form.Language,
+		Visibility:       form.Visibility,
+		Tags:             parseTags(form.Tags),
+		Files:            files,
+		BurnAfterReading: form.BurnAfterReading,
+		Encrypted:        form.Encrypted,
+		Expires:          expires,
+	}
+
+	// the server cannot highlight or render the content it cannot read
+	if snippet.Encrypted {
+		snippet.Format = "plain"
+		snippet.Language = "plaintext"
+	}
+
+	return snippet
+}
+
+// revisionCompareForm represent the revisions picked for comparison on the "snippet history" page
+type revisionCompareForm struct {
+	From int
+	To   int
+}
+
 // userSignupForm represent the form data and validation errors for the "user signup" form fields
 type userSignupForm struct {
 	Name                string `form:"name"`
@@ -40,6 +287,17 @@ type accountPasswordUpdateForm struct {
 	validator.Validator     `form:"-"`
 }
 
+// tokenCreateForm represent the form data and validation errors for the "new API token" form fields
+type tokenCreateForm struct {
+	Name                string `form:"name"`
+	Scope               string `form:"scope"`
+	ExpiresIn           int    `form:"expiresIn"`
+	validator.Validator `form:"-"`
+}
+
+// tokenExpiryDays lists the permitted lifetimes of a personal API token in days
+var tokenExpiryDays = []int{7, 30, 90, 365}
+
 // home displays the home page
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	snippets, err := app.snippets.Latest()
@@ -56,14 +314,185 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 
 // snippetView display a specific snippet
 func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil || id < 1 {
-		app.notFound(w)
+	snippet := app.readSnippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+
+	// the parent is only linked while it is live and visible to the current user
+	if snippet.ParentID != 0 {
+		parent, err := app.snippets.Get(snippet.ParentID)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+		if parent != nil && parent.VisibleTo(app.authenticatedUserID(r)) {
+			data.Parent = parent
+		}
+	}
+
+	app.render(w, r, http.StatusOK, "view.tmpl", data)
+}
+
+// snippetRaw sends the snippet content as plain text
+func (app *application) snippetRaw(w http.ResponseWriter, r *http.Request) {
+	snippet := app.readSnippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(snippet.Content))
+}
+
+// snippetDownload sends the snippet content as a file attachment named after the snippet title and language
+func (app *application) snippetDownload(w http.ResponseWriter, r *http.Request) {
+	snippet := app.readSnippetFromPath(w, r)
+	if snippet == nil {
 		return
 	}
 
-	snippet, err := app.snippets.Get(id)
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": snippetFilename(snippet)})
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", disposition)
+	w.Write([]byte(snippet.Content))
+}
+
+// snippetZip sends the snippet content together with its additional files as a zip archive
+func (app *application) snippetZip(w http.ResponseWriter, r *http.Request) {
+	snippet := app.readSnippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	buf, err := snippetZipArchive(snippet)
 	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	name := snippetFilename(snippet)
+	name = strings.TrimSuffix(name, path.Ext(name)) + ".zip"
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", disposition)
+	buf.WriteTo(w)
+}
+
+// snippetHistory displays the revisions of a snippet to its owner and the unified diff between two of them,
+// by default between the last two revisions
+func (app *application) snippetHistory(w http.ResponseWriter, r *http.Request) {
+	// the revisions keep whatever the owner has edited out of the snippet, so only the owner may read them
+	snippet := app.snippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	revisions, err := app.revisions.List(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	var form revisionCompareForm
+	if n := len(revisions); n >= 2 {
+		form.From = revisions[n-2].ID
+		form.To = revisions[n-1].ID
+	}
+
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	form.From = app.readInt(qs, "from", form.From, &v)
+	form.To = app.readInt(qs, "to", form.To, &v)
+
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+	data.Form = form
+
+	if form.From != 0 || form.To != 0 {
+		from := findRevision(revisions, form.From)
+		to := findRevision(revisions, form.To)
+		if from == nil || to == nil {
+			app.notFound(w)
+			return
+		}
+
+		data.Diff, err = unifiedDiff(from, to)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.render(w, r, http.StatusOK, "history.tmpl", data)
+}
+
+// snippetUnlockPost checks the password of a protected snippet and, if it is correct, remembers
+// the snippet as unlocked in the session. Failed attempts are rate limited per snippet
+func (app *application) snippetUnlockPost(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	var form snippetUnlockForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// only ever return to one of the pages of this snippet
+	readPaths := []string{
+		fmt.Sprintf("/s/%s", snippet.Slug),
+		fmt.Sprintf("/snippet/view/%d", snippet.ID),
+		fmt.Sprintf("/snippet/raw/%s", snippet.Slug),
+		fmt.Sprintf("/snippet/download/%s", snippet.Slug),
+		fmt.Sprintf("/snippet/zip/%s", snippet.Slug),
+	}
+	if !validator.PermittedValue(form.Next, readPaths...) {
+		form.Next = readPaths[0]
+	}
+
+	form.CheckField(
+		validator.NotBlank(form.Password),
+		"password",
+		"This field cannot be blank")
+
+	if !form.Valid() {
+		app.renderUnlock(w, r, http.StatusUnprocessableEntity, snippet, form)
+		return
+	}
+
+	// the attempt is counted before the slow password check, so that parallel guesses cannot all get through,
+	// and given back unless the password turns out to be incorrect
+	if !app.unlockLimiter.Reserve(snippet.ID) {
+		form.AddNonFieldError("Too many incorrect passwords, please try again later")
+		app.renderUnlock(w, r, http.StatusTooManyRequests, snippet, form)
+		return
+	}
+
+	err = app.snippets.Unlock(snippet.ID, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			form.AddNonFieldError("Password is incorrect")
+			app.renderUnlock(w, r, http.StatusUnprocessableEntity, snippet, form)
+			return
+		}
+
+		app.unlockLimiter.Refund(snippet.ID)
 		if errors.Is(err, models.ErrNoRecord) {
 			app.notFound(w)
 		} else {
@@ -72,17 +501,107 @@ func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.unlockLimiter.Refund(snippet.ID)
+
+	unlockedIDs, _ := app.sessionManager.Get(r.Context(), "unlockedSnippetIDs").([]int)
+	app.sessionManager.Put(r.Context(), "unlockedSnippetIDs", append(unlockedIDs, snippet.ID))
+
+	http.Redirect(w, r, form.Next, http.StatusSeeOther)
+}
+
+// snippetArchive displays all the live snippets page by page
+func (app *application) snippetArchive(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	page := app.readInt(qs, "page", 1, &v)
+	pageSize := app.readInt(qs, "size", 20, &v)
+	beforeID := app.readInt(qs, "before", 0, &v)
+	afterID := app.readInt(qs, "after", 0, &v)
+
+	v.CheckField(page > 0, "page", "must be greater than zero")
+	v.CheckField(pageSize > 0 && pageSize <= 100, "size", "must be between 1 and 100")
+	v.CheckField(beforeID >= 0, "before", "must not be negative")
+	v.CheckField(afterID >= 0, "after", "must not be negative")
+	v.CheckField(beforeID == 0 || afterID == 0, "after", "cannot be used together with before")
+
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.Archive(beforeID, afterID, page, pageSize)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	data := app.newTemplateData(r)
-	data.Snippet = snippet
+	data.Snippets = snippets
+	data.Metadata = metadata
 
-	app.render(w, r, http.StatusOK, "view.tmpl", data)
+	app.render(w, r, http.StatusOK, "archive.tmpl", data)
+}
+
+// search displays the live snippets matching the query
+func (app *application) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	data := app.newTemplateData(r)
+	data.Query = query
+
+	if !validator.NotBlank(query) {
+		app.render(w, r, http.StatusOK, "search.tmpl", data)
+		return
+	}
+
+	if !validator.MaxChars(query, 100) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, err := app.snippets.Search(query)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data.Snippets = snippets
+
+	app.render(w, r, http.StatusOK, "search.tmpl", data)
+}
+
+// tagView displays the live snippets with the tag
+func (app *application) tagView(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	if !validator.MaxChars(tag, 32) || !validator.Matches(tag, validator.TagRX) {
+		app.notFound(w)
+		return
+	}
+
+	snippets, err := app.snippets.ByTag(tag)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tag = tag
+	data.Snippets = snippets
+
+	app.render(w, r, http.StatusOK, "tag.tmpl", data)
 }
 
 // snippetCreate display a form for creating a new snippet
 func (app *application) snippetCreate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = snippetCreateForm{
-		Expires: 365,
+		Format:      "plain",
+		Language:    "plaintext",
+		Visibility:  "public",
+		Expires:     "in",
+		ExpiresIn:   365,
+		ExpiresUnit: "days",
 	}
 	app.render(w, r, http.StatusOK, "create.tmpl", data)
 }
@@ -96,22 +615,7 @@ func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	form.CheckField(
-		validator.NotBlank(form.Title),
-		"title",
-		"This field cannot be blank")
-	form.CheckField(
-		validator.MaxChars(form.Title, 100),
-		"title",
-		"This field cannot be more than 100 characters long")
-	form.CheckField(
-		validator.NotBlank(form.Content),
-		"content",
-		"This field cannot be blank")
-	form.CheckField(
-		validator.PermittedValue(form.Expires, 1, 7, 31, 365),
-		"expires",
-		"This field must equal 1, 7, 31 or 365")
+	snippet := form.snippet(time.Now().UTC())
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
@@ -120,14 +624,177 @@ func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	id, err := app.snippets.Insert(form.Title, form.Content, form.Expires)
+	snippet.Owner = models.User{ID: app.authenticatedUserID(r)}
+
+	_, err = app.snippets.Insert(snippet, form.Password)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
 	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
-	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/s/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// snippetForkPost saves a copy of a snippet owned by the current user, which records the snippet
+// it was forked from, and opens it for editing
+func (app *application) snippetForkPost(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetFromPath(w, r)
+	if snippet == nil {
+		return
+	}
+
+	// forking must not become a way around the password, the burn or the encryption of a snippet. The fork of
+	// an unlocked protected snippet would republish its content without the password, so those are refused too
+	if snippet.Protected || snippet.BurnAfterReading || snippet.Encrypted {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	fork := &models.Snippet{
+		Title:      snippet.Title,
+		Content:    snippet.Content,
+		Format:     snippet.Format,
+		Language:   snippet.Language,
+		Visibility: snippet.Visibility,
+		Tags:       snippet.Tags,
+		Files:      snippet.Files,
+		ParentID:   snippet.ID,
+		Expires:    snippet.Expires,
+		Owner:      models.User{ID: app.authenticatedUserID(r)},
+	}
+
+	_, err := app.snippets.Insert(fork, "")
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully forked!")
+	http.Redirect(w, r, fmt.Sprintf("/snippet/edit/%s", fork.Slug), http.StatusSeeOther)
+}
+
+// snippetOwned fetches the snippet from the {slug} path value and makes sure it belongs to the current user.
+// It sends the appropriate error response and returns nil if the snippet cannot be edited by the user
+func (app *application) snippetOwned(w http.ResponseWriter, r *http.Request) *models.Snippet {
+	snippet := app.snippetFromPath(w, r)
+	if snippet == nil {
+		return nil
+	}
+
+	if snippet.Owner.ID != app.authenticatedUserID(r) {
+		app.clientError(w, http.StatusForbidden)
+		return nil
+	}
+
+	return snippet
+}
+
+// snippetEdit displays a form for editing an existing snippet
+func (app *application) snippetEdit(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	// the content of an encrypted snippet can only be changed in the browser holding the key
+	if snippet.Encrypted {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Form = snippetCreateForm{
+		Title:      snippet.Title,
+		Content:    snippet.Content,
+		Format:     snippet.Format,
+		Language:   snippet.Language,
+		Visibility: snippet.Visibility,
+		Tags:       strings.Join(snippet.Tags, ", "),
+	}
+	app.render(w, r, http.StatusOK, "edit.tmpl", data)
+}
+
+// snippetEditPost saves the changes to an existing snippet
+func (app *application) snippetEditPost(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	if snippet.Encrypted {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	var form snippetCreateForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+	form.checkFilename(snippet.Files)
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "edit.tmpl", data)
+		return
+	}
+
+	snippet.Title = form.Title
+	snippet.Content = form.Content
+	snippet.Format = form.Format
+	snippet.Language = form.Language
+	snippet.Visibility = form.Visibility
+	snippet.Tags = parseTags(form.Tags)
+
+	err = app.snippets.Update(snippet, app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully updated!")
+	http.Redirect(w, r, fmt.Sprintf("/s/%s", snippet.Slug), http.StatusSeeOther)
+}
+
+// snippetDeletePost permanently deletes a snippet
+func (app *application) snippetDeletePost(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully deleted!")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// snippetExpirePost expires a snippet immediately instead of waiting for its expiry date
+func (app *application) snippetExpirePost(w http.ResponseWriter, r *http.Request) {
+	snippet := app.snippetOwned(w, r)
+	if snippet == nil {
+		return
+	}
+
+	err := app.snippets.ExpireNow(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully expired!")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 // userSignup displays a form for signing up a new user
@@ -231,8 +898,12 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	id, err := app.users.Authenticate(form.Email, form.Password)
 	if err != nil {
-		if errors.Is(err, models.ErrInvalidCredentials) {
-			form.AddNonFieldError("Email or password is incorrect")
+		if errors.Is(err, models.ErrInvalidCredentials) || errors.Is(err, models.ErrAccountDisabled) {
+			if errors.Is(err, models.ErrAccountDisabled) {
+				form.AddNonFieldError("Your account has been disabled")
+			} else {
+				form.AddNonFieldError("Email or password is incorrect")
+			}
 
 			data := app.newTemplateData(r)
 			data.Form = form
@@ -314,6 +985,42 @@ func (app *application) accountView(w http.ResponseWriter, r *http.Request) {
 	app.render(w, r, http.StatusOK, "account.tmpl", data)
 }
 
+// accountSnippets displays a sortable, paginated list of all the snippets of the current user,
+// including the expired ones
+func (app *application) accountSnippets(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	page := app.readInt(qs, "page", 1, &v)
+	pageSize := app.readInt(qs, "size", 20, &v)
+	sort := qs.Get("sort")
+	if sort == "" {
+		sort = "-created"
+	}
+
+	v.CheckField(page > 0, "page", "must be greater than zero")
+	v.CheckField(pageSize > 0 && pageSize <= 100, "size", "must be between 1 and 100")
+	v.CheckField(validator.PermittedValue(sort, models.SnippetSortValues...), "sort", "invalid sort value")
+
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.ListByUser(app.authenticatedUserID(r), sort, page, pageSize)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Metadata = metadata
+	data.Sort = sort
+
+	app.render(w, r, http.StatusOK, "mysnippets.tmpl", data)
+}
+
 // accountPasswordUpdate displays 'change password' page
 func (app *application) accountPasswordUpdate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
@@ -384,3 +1091,88 @@ func (app *application) accountPasswordUpdatePost(w http.ResponseWriter, r *http
 	app.sessionManager.Put(r.Context(), "flash", "Your password has been successfully updated.")
 	http.Redirect(w, r, "/account/view", http.StatusSeeOther)
 }
+
+// accountTokens displays the personal API tokens of the user and a form for creating a new one
+func (app *application) accountTokens(w http.ResponseWriter, r *http.Request) {
+	app.renderTokens(w, r, http.StatusOK, tokenCreateForm{Scope: "read", ExpiresIn: 30})
+}
+
+// accountTokensPost creates a new personal API token and redirects to the tokens page, which displays it once.
+// Only its hash is saved, the plaintext token is kept in the session until that page pops it
+func (app *application) accountTokensPost(w http.ResponseWriter, r *http.Request) {
+	var form tokenCreateForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(
+		validator.NotBlank(form.Name),
+		"name",
+		"This field cannot be blank")
+	form.CheckField(
+		validator.MaxChars(form.Name, 100),
+		"name",
+		"This field cannot be more than 100 characters long")
+	form.CheckField(
+		validator.PermittedValue(form.Scope, models.TokenScopes...),
+		"scope",
+		"This field must equal read or write")
+	form.CheckField(
+		validator.PermittedValue(form.ExpiresIn, tokenExpiryDays...),
+		"expiresIn",
+		"This field must equal 7, 30, 90 or 365 days")
+
+	if !form.Valid() {
+		app.renderTokens(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	token, err := app.tokens.New(app.authenticatedUserID(r), form.Name, form.Scope, time.Duration(form.ExpiresIn)*24*time.Hour)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "newToken", token.Plaintext)
+	app.sessionManager.Put(r.Context(), "flash", "API token successfully created!")
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// accountTokenRevokePost permanently deletes a personal API token of the user
+func (app *application) accountTokenRevokePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		app.notFound(w)
+		return
+	}
+
+	err = app.tokens.Delete(id, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully revoked!")
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// renderTokens renders the "API tokens" page, newToken is the token just created or nil
+func (app *application) renderTokens(w http.ResponseWriter, r *http.Request, status int, form tokenCreateForm) {
+	tokens, err := app.tokens.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tokens = tokens
+	data.NewToken = app.sessionManager.PopString(r.Context(), "newToken")
+	data.Form = form
+	app.render(w, r, status, "tokens.tmpl", data)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"archive/zip"
 	"asniki/snippetbox/internal/assert"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPing(t *testing.T) {
@@ -41,6 +45,21 @@ func TestSnippetView(t *testing.T) {
 			urlPath:  "/snippet/view/2",
 			wantCode: http.StatusNotFound,
 		},
+		{
+			name:     "Private ID",
+			urlPath:  "/snippet/view/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unlisted ID",
+			urlPath:  "/snippet/view/7",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "ID of a snippet created after the share slugs",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusNotFound,
+		},
 		{
 			name:     "Negative ID",
 			urlPath:  "/snippet/view/-1",
@@ -61,6 +80,27 @@ func TestSnippetView(t *testing.T) {
 			urlPath:  "/snippet/view/",
 			wantCode: http.StatusNotFound,
 		},
+		{
+			name:     "Valid slug",
+			urlPath:  "/s/AnOldSilent1",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond...",
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/s/AnOldSilent2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Malformed slug",
+			urlPath:  "/s/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private slug",
+			urlPath:  "/s/LightningFl4",
+			wantCode: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,6 +233,58 @@ func TestUserSignup(t *testing.T) {
 	}
 }
 
+func TestUserLogin(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid credentials",
+			email:    "bob@example.com",
+			password: "validPa$$word",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Wrong password",
+			email:    "bob@example.com",
+			password: "wrongPa$$word",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Email or password is incorrect",
+		},
+		{
+			name:     "Disabled account",
+			email:    "carol@example.com",
+			password: "validPa$$word",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Your account has been disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, "/user/login", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
 func TestSnippetCreate(t *testing.T) {
 	app := newTestApplication(t)
 	ts := newTestServer(t, app.routes())
@@ -223,5 +315,1301 @@ func TestSnippetCreate(t *testing.T) {
 
 		assert.Equal(t, code, http.StatusOK)
 		assert.StringContains(t, body, "<form action='/snippet/create' method='POST'>")
+
+		form = url.Values{}
+		form.Add("title", "A new snippet")
+		form.Add("content", "Some content")
+		form.Add("format", "code")
+		form.Add("language", "go")
+		form.Add("visibility", "unlisted")
+		form.Add("expires", "in")
+		form.Add("expiresIn", "7")
+		form.Add("expiresUnit", "days")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, headers, _ := ts.postForm(t, "/snippet/create", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/s/NewSnippet02")
+	})
+}
+
+func TestSnippetEdit(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/edit/AnOldSilent1")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	validCSRFToken := ts.login(t)
+
+	t.Run("Form", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			urlPath  string
+			wantCode int
+			wantBody string
+		}{
+			{
+				name:     "Own snippet",
+				urlPath:  "/snippet/edit/AnOldSilent1",
+				wantCode: http.StatusOK,
+				wantBody: "<form action='/snippet/edit/AnOldSilent1' method='POST'>",
+			},
+			{
+				name:     "Foreign snippet",
+				urlPath:  "/snippet/edit/WintryForest",
+				wantCode: http.StatusForbidden,
+			},
+			{
+				name:     "Non-existent ID",
+				urlPath:  "/snippet/edit/NoSuchSnip02",
+				wantCode: http.StatusNotFound,
+			},
+			{
+				name:     "String ID",
+				urlPath:  "/snippet/edit/foo",
+				wantCode: http.StatusNotFound,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				code, _, body := ts.get(t, tt.urlPath)
+
+				assert.Equal(t, code, tt.wantCode)
+
+				if tt.wantBody != "" {
+					assert.StringContains(t, body, tt.wantBody)
+				}
+			})
+		}
+	})
+
+	t.Run("Submit", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			urlPath      string
+			title        string
+			content      string
+			format       string
+			language     string
+			wantCode     int
+			wantLocation string
+		}{
+			{
+				name:         "Valid submission",
+				urlPath:      "/snippet/edit/AnOldSilent1",
+				title:        "Updated title",
+				content:      "Updated content",
+				format:       "code",
+				language:     "go",
+				wantCode:     http.StatusSeeOther,
+				wantLocation: "/s/AnOldSilent1",
+			},
+			{
+				name:     "Empty title",
+				urlPath:  "/snippet/edit/AnOldSilent1",
+				title:    "",
+				content:  "Updated content",
+				format:   "code",
+				language: "go",
+				wantCode: http.StatusUnprocessableEntity,
+			},
+			{
+				name:     "Title of a file",
+				urlPath:  "/snippet/edit/AnOldSilent1",
+				title:    "Frog",
+				content:  "Updated content",
+				format:   "code",
+				language: "go",
+				wantCode: http.StatusUnprocessableEntity,
+			},
+			{
+				name:     "Unsupported format",
+				urlPath:  "/snippet/edit/AnOldSilent1",
+				title:    "Updated title",
+				content:  "Updated content",
+				format:   "pdf",
+				language: "go",
+				wantCode: http.StatusUnprocessableEntity,
+			},
+			{
+				name:     "Unsupported language",
+				urlPath:  "/snippet/edit/AnOldSilent1",
+				title:    "Updated title",
+				content:  "Updated content",
+				format:   "code",
+				language: "cobol",
+				wantCode: http.StatusUnprocessableEntity,
+			},
+			{
+				name:     "Foreign snippet",
+				urlPath:  "/snippet/edit/WintryForest",
+				title:    "Updated title",
+				content:  "Updated content",
+				wantCode: http.StatusForbidden,
+			},
+			{
+				name:     "Non-existent ID",
+				urlPath:  "/snippet/edit/NoSuchSnip02",
+				title:    "Updated title",
+				content:  "Updated content",
+				wantCode: http.StatusNotFound,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				form := url.Values{}
+				form.Add("title", tt.title)
+				form.Add("content", tt.content)
+				form.Add("format", tt.format)
+				form.Add("language", tt.language)
+				form.Add("visibility", "public")
+				form.Add("csrf_token", validCSRFToken)
+
+				code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+				assert.Equal(t, code, tt.wantCode)
+				assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			})
+		}
+	})
+}
+
+func TestSnippetDeleteAndExpire(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	validCSRFToken := ts.login(t)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		csrfToken    string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Delete own snippet",
+			urlPath:      "/snippet/delete/AnOldSilent1",
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/",
+		},
+		{
+			name:      "Delete without CSRF token",
+			urlPath:   "/snippet/delete/AnOldSilent1",
+			csrfToken: "wrongToken",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Delete foreign snippet",
+			urlPath:   "/snippet/delete/WintryForest",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name:      "Delete non-existent snippet",
+			urlPath:   "/snippet/delete/NoSuchSnip02",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusNotFound,
+		},
+		{
+			name:         "Expire own snippet",
+			urlPath:      "/snippet/expire/AnOldSilent1",
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/",
+		},
+		{
+			name:      "Expire foreign snippet",
+			urlPath:   "/snippet/expire/WintryForest",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name:      "Expire non-existent snippet",
+			urlPath:   "/snippet/expire/NoSuchSnip02",
+			csrfToken: validCSRFToken,
+			wantCode:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Flash message", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+		ts.postForm(t, "/snippet/delete/AnOldSilent1", form)
+
+		_, _, body := ts.get(t, "/")
+		assert.StringContains(t, body, "Snippet successfully deleted!")
+	})
+}
+
+func TestAccountSnippets(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/account/snippets")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
 	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Default",
+			urlPath:  "/account/snippets",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Sorted by title",
+			urlPath:  "/account/snippets?sort=-title",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/account/snippets?sort=title'>Title</a>",
+		},
+		{
+			name:     "Empty page",
+			urlPath:  "/account/snippets?page=2",
+			wantCode: http.StatusOK,
+			wantBody: "You haven't created any snippets yet.",
+		},
+		{
+			name:     "Invalid sort",
+			urlPath:  "/account/snippets?sort=content",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Zero page",
+			urlPath:  "/account/snippets?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "String page",
+			urlPath:  "/account/snippets?page=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Page size too large",
+			urlPath:  "/account/snippets?size=1000",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetArchive(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "First page",
+			urlPath:  "/snippets",
+			wantCode: http.StatusOK,
+			wantBody: "Page 1 of 1 (1 snippets)",
+		},
+		{
+			name:     "Older page",
+			urlPath:  "/snippets?before=1&page=2",
+			wantCode: http.StatusOK,
+			wantBody: "There's nothing to see here... yet!",
+		},
+		{
+			name:     "Both cursors",
+			urlPath:  "/snippets?before=5&after=1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Negative cursor",
+			urlPath:  "/snippets?before=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Zero page size",
+			urlPath:  "/snippets?size=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Empty query",
+			urlPath:  "/search",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/search' method='GET'>",
+		},
+		{
+			name:     "Matching query",
+			urlPath:  "/search?q=pond",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent <mark>pond</mark>",
+		},
+		{
+			name:     "No results",
+			urlPath:  "/search?q=frogs",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets match your search.",
+		},
+		{
+			name:     "Query too long",
+			urlPath:  "/search?q=" + strings.Repeat("a", 101),
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetRawAndDownload(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		urlPath         string
+		wantCode        int
+		wantContentType string
+		wantDisposition string
+		wantBody        string
+	}{
+		{
+			name:            "Raw",
+			urlPath:         "/snippet/raw/AnOldSilent1",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "An old silent pond...",
+		},
+		{
+			name:     "Raw non-existent ID",
+			urlPath:  "/snippet/raw/NoSuchSnip02",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Raw string ID",
+			urlPath:  "/snippet/raw/foo",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:            "Download",
+			urlPath:         "/snippet/download/AnOldSilent1",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/octet-stream",
+			wantDisposition: "attachment; filename=an-old-silent-pond.txt",
+			wantBody:        "An old silent pond...",
+		},
+		{
+			name:     "Download non-existent ID",
+			urlPath:  "/snippet/download/NoSuchSnip02",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantContentType != "" {
+				assert.Equal(t, headers.Get("Content-Type"), tt.wantContentType)
+			}
+			assert.Equal(t, headers.Get("Content-Disposition"), tt.wantDisposition)
+
+			if tt.wantBody != "" {
+				assert.Equal(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetHistory(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/history/AnOldSilent1")
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "Latest changes",
+			urlPath:  "/snippet/history/AnOldSilent1",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"<span class='del'>-An old pond...</span>",
+				"<span class='add'>&#43;An old silent pond...</span>",
+			},
+		},
+		{
+			name:     "Same revision",
+			urlPath:  "/snippet/history/AnOldSilent1?from=2&to=2",
+			wantCode: http.StatusOK,
+			wantBody: []string{"No differences"},
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/history/AnOldSilent1?from=5&to=2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String revision",
+			urlPath:  "/snippet/history/AnOldSilent1?from=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Foreign snippet",
+			urlPath:  "/snippet/history/WintryForest",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/snippet/history/NoSuchSnip02",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
+			}
+		})
+	}
+}
+
+func TestPrivateSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	urlPaths := []string{
+		"/s/LightningFl4",
+		"/snippet/raw/LightningFl4",
+		"/snippet/download/LightningFl4",
+	}
+
+	t.Run("Anonymous", func(t *testing.T) {
+		for _, urlPath := range urlPaths {
+			code, _, _ := ts.get(t, urlPath)
+			assert.Equal(t, code, http.StatusNotFound)
+		}
+	})
+
+	ts.login(t)
+
+	t.Run("Owner", func(t *testing.T) {
+		for _, urlPath := range urlPaths {
+			code, _, _ := ts.get(t, urlPath)
+			assert.Equal(t, code, http.StatusOK)
+		}
+
+		_, _, body := ts.get(t, "/s/LightningFl4")
+		assert.StringContains(t, body, "A lightning flash...")
+	})
+}
+
+func TestBurnAfterReading(t *testing.T) {
+	t.Run("Preview", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		for _, urlPath := range []string{"/s/ThisIsGone05", "/snippet/raw/ThisIsGone05", "/snippet/download/ThisIsGone05"} {
+			rs, err := ts.Client().Head(ts.URL + urlPath)
+			assert.NilError(t, err)
+			rs.Body.Close()
+			assert.Equal(t, rs.StatusCode, http.StatusOK)
+
+			code, _, body := ts.get(t, urlPath)
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, fmt.Sprintf("<form action='%s' method='POST' data-keep-fragment>", urlPath))
+			assert.Equal(t, strings.Contains(body, "s3cr3t-t0k3n"), false)
+		}
+	})
+
+	t.Run("Reader", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/s/ThisIsGone05")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, _, body := ts.postForm(t, "/s/ThisIsGone05", form)
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "s3cr3t-t0k3n")
+		assert.StringContains(t, body, "This snippet was burned after reading and is now gone.")
+
+		code, _, _ = ts.postForm(t, "/snippet/raw/ThisIsGone05", form)
+		assert.Equal(t, code, http.StatusNotFound)
+
+		code, _, _ = ts.get(t, "/s/ThisIsGone05")
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/snippet/raw/ThisIsGone05")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, _, body := ts.postForm(t, "/snippet/raw/ThisIsGone05", form)
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, body, "s3cr3t-t0k3n")
+
+		code, _, _ = ts.postForm(t, "/snippet/raw/ThisIsGone05", form)
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	t.Run("Without a CSRF token", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, _, _ := ts.postForm(t, "/snippet/raw/ThisIsGone05", url.Values{})
+		assert.Equal(t, code, http.StatusBadRequest)
+
+		code, _, body := ts.get(t, "/snippet/raw/ThisIsGone05")
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, strings.Contains(body, "s3cr3t-t0k3n"), false)
+	})
+
+	t.Run("Owner", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t)
+
+		code, _, body := ts.get(t, "/s/ThisIsGone05")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "This snippet will be deleted as soon as someone else reads it.")
+
+		code, _, _ = ts.get(t, "/snippet/history/ThisIsGone05")
+		assert.Equal(t, code, http.StatusOK)
+
+		code, _, _ = ts.get(t, "/snippet/raw/ThisIsGone05")
+		assert.Equal(t, code, http.StatusOK)
+	})
+}
+
+func TestProtectedSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	var validCSRFToken string
+
+	t.Run("Locked", func(t *testing.T) {
+		for _, urlPath := range []string{"/s/OpenSesame06", "/snippet/raw/OpenSesame06", "/snippet/download/OpenSesame06"} {
+			code, _, body := ts.get(t, urlPath)
+			assert.Equal(t, code, http.StatusForbidden)
+			assert.StringContains(t, body, "<form action='/snippet/unlock/OpenSesame06' method='POST' novalidate>")
+			assert.StringContains(t, body, fmt.Sprintf("<input type='hidden' name='next' value='%s'>", urlPath))
+
+			validCSRFToken = extractCSRFToken(t, body)
+		}
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("password", "abracadabra")
+		form.Add("next", "/snippet/raw/OpenSesame06")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, body := ts.postForm(t, "/snippet/unlock/OpenSesame06", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "Password is incorrect")
+	})
+
+	t.Run("Correct password", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("password", "open sesame")
+		form.Add("next", "https://example.com/")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, headers, _ := ts.postForm(t, "/snippet/unlock/OpenSesame06", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/s/OpenSesame06")
+
+		code, _, body := ts.get(t, "/snippet/raw/OpenSesame06")
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, body, "Forty thieves...")
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/s/OpenSesame06")
+
+		form := url.Values{}
+		form.Add("password", "abracadabra")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		for range 5 {
+			code, _, _ := ts.postForm(t, "/snippet/unlock/OpenSesame06", form)
+			assert.Equal(t, code, http.StatusUnprocessableEntity)
+		}
+
+		form.Set("password", "open sesame")
+
+		code, _, body := ts.postForm(t, "/snippet/unlock/OpenSesame06", form)
+		assert.Equal(t, code, http.StatusTooManyRequests)
+		assert.StringContains(t, body, "Too many incorrect passwords")
+	})
+}
+
+func TestEncryptedSnippet(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("View", func(t *testing.T) {
+		code, _, body := ts.get(t, "/s/Encrypted007")
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<code id='encrypted-content' data-ciphertext='q83vASNFZ4mrze8BI0VniQ=='>")
+		assert.StringContains(t, body, "<script src='/static/js/encrypt.js' type='text/javascript'></script>")
+	})
+
+	validCSRFToken := ts.login(t)
+
+	t.Run("Edit", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippet/edit/Encrypted007")
+		assert.Equal(t, code, http.StatusForbidden)
+	})
+
+	tests := []struct {
+		name     string
+		content  string
+		wantCode int
+	}{
+		{
+			name:     "Ciphertext",
+			content:  "q83vASNFZ4mrze8BI0VniQ==",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Plaintext",
+			content:  "Not encrypted at all",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("Create/"+tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", "Launch codes")
+			form.Add("content", tt.content)
+			form.Add("format", "plain")
+			form.Add("language", "plaintext")
+			form.Add("visibility", "unlisted")
+			form.Add("encrypted", "true")
+			form.Add("expires", "in")
+			form.Add("expiresIn", "7")
+			form.Add("expiresUnit", "days")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, "/snippet/create", form)
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestSnippetCreateExpiry(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	validCSRFToken := ts.login(t)
+
+	tests := []struct {
+		name     string
+		expiry   url.Values
+		wantCode int
+	}{
+		{
+			name:     "In minutes",
+			expiry:   url.Values{"expires": {"in"}, "expiresIn": {"30"}, "expiresUnit": {"minutes"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "In hours",
+			expiry:   url.Values{"expires": {"in"}, "expiresIn": {"12"}, "expiresUnit": {"hours"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "In zero days",
+			expiry:   url.Values{"expires": {"in"}, "expiresIn": {"0"}, "expiresUnit": {"days"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "In too many days",
+			expiry:   url.Values{"expires": {"in"}, "expiresIn": {"3651"}, "expiresUnit": {"days"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid unit",
+			expiry:   url.Values{"expires": {"in"}, "expiresIn": {"2"}, "expiresUnit": {"weeks"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "At future date",
+			expiry:   url.Values{"expires": {"at"}, "expiresAt": {time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02T15:04")}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "At past date",
+			expiry:   url.Values{"expires": {"at"}, "expiresAt": {"2020-01-01T10:00"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "At invalid date",
+			expiry:   url.Values{"expires": {"at"}, "expiresAt": {"tomorrow"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Never",
+			expiry:   url.Values{"expires": {"never"}},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Days only",
+			expiry:   url.Values{"expires": {"7"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := tt.expiry
+			form.Add("title", "A new snippet")
+			form.Add("content", "Some content")
+			form.Add("format", "plain")
+			form.Add("language", "plaintext")
+			form.Add("visibility", "public")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, "/snippet/create", form)
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Chips", func(t *testing.T) {
+		for _, urlPath := range []string{"/", "/s/AnOldSilent1"} {
+			_, _, body := ts.get(t, urlPath)
+			assert.StringContains(t, body, "<a class='tag' href='/tags/haiku'>haiku</a>")
+		}
+	})
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Tag with snippets",
+			urlPath:  "/tags/haiku",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/s/AnOldSilent1'>An old silent pond</a>",
+		},
+		{
+			name:     "Tag without snippets",
+			urlPath:  "/tags/prose",
+			wantCode: http.StatusOK,
+			wantBody: "There are no snippets with this tag.",
+		},
+		{
+			name:     "Invalid tag",
+			urlPath:  "/tags/Not_A_Tag",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, tt.wantCode)
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	validCSRFToken := ts.login(t)
+
+	createTests := []struct {
+		name     string
+		tags     string
+		wantCode int
+	}{
+		{
+			name:     "Valid tags",
+			tags:     "Go, web-dev, go",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Invalid tag",
+			tags:     "go, c++",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Too long tag",
+			tags:     strings.Repeat("a", 33),
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Too many tags",
+			tags:     "a,b,c,d,e,f,g,h,i,j,k",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range createTests {
+		t.Run("Create/"+tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", "A new snippet")
+			form.Add("content", "Some content")
+			form.Add("format", "plain")
+			form.Add("language", "plaintext")
+			form.Add("visibility", "public")
+			form.Add("tags", tt.tags)
+			form.Add("expires", "never")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, _ := ts.postForm(t, "/snippet/create", form)
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestSnippetFork(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Forked from", func(t *testing.T) {
+		_, _, body := ts.get(t, "/s/WintryForest")
+		assert.StringContains(t, body, "<em>forked from <a href='/s/AnOldSilent1'>An old silent pond</a></em>")
+
+		_, _, body = ts.get(t, "/snippet/view/1")
+		assert.StringContains(t, body, "<span>1 fork</span>")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, _, body := ts.get(t, "/user/login")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/snippet/fork/WintryForest", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	validCSRFToken := ts.login(t)
+
+	t.Run("Unlocked protected snippet", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("password", "open sesame")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/snippet/unlock/OpenSesame06", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		_, _, body := ts.get(t, "/s/OpenSesame06")
+		assert.Equal(t, strings.Contains(body, "<button>Fork</button>"), false)
+
+		form = url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ = ts.postForm(t, "/snippet/fork/OpenSesame06", form)
+		assert.Equal(t, code, http.StatusForbidden)
+	})
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Foreign snippet",
+			urlPath:      "/snippet/fork/WintryForest",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/edit/NewSnippet02",
+		},
+		{
+			name:         "Own snippet",
+			urlPath:      "/snippet/fork/AnOldSilent1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/edit/NewSnippet02",
+		},
+		{
+			name:     "Protected snippet",
+			urlPath:  "/snippet/fork/OpenSesame06",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Encrypted snippet",
+			urlPath:  "/snippet/fork/Encrypted007",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/snippet/fork/NoSuchSnip99",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestSnippetFiles(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("View", func(t *testing.T) {
+		_, _, body := ts.get(t, "/snippet/view/1")
+		assert.StringContains(t, body, "<strong>frog.go</strong>")
+		assert.StringContains(t, body, "<a href='/snippet/zip/AnOldSilent1'>Download zip</a>")
+	})
+
+	t.Run("Zip", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/snippet/zip/AnOldSilent1")
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Type"), "application/zip")
+		assert.Equal(t, headers.Get("Content-Disposition"), "attachment; filename=an-old-silent-pond.zip")
+
+		zr, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+		assert.NilError(t, err)
+		assert.Equal(t, len(zr.File), 2)
+		assert.Equal(t, zr.File[0].Name, "an-old-silent-pond.txt")
+		assert.Equal(t, zr.File[1].Name, "frog.go")
+	})
+
+	validCSRFToken := ts.login(t)
+
+	tests := []struct {
+		name     string
+		files    url.Values
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "Valid files",
+			files: url.Values{
+				"files[0].name": {"Dockerfile"}, "files[0].language": {"dockerfile"}, "files[0].content": {"FROM alpine"},
+				"files[2].name": {"run.sh"}, "files[2].language": {"bash"}, "files[2].content": {"echo hi"},
+			},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name: "Blank rows",
+			files: url.Values{
+				"files[0].name": {""}, "files[0].language": {"plaintext"}, "files[0].content": {""},
+			},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name: "Path in name",
+			files: url.Values{
+				"files[0].name": {"../etc/passwd"}, "files[0].language": {"plaintext"}, "files[0].content": {"root"},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "File 1: the name can only contain letters, digits, dots, dashes and underscores",
+		},
+		{
+			name: "Duplicate name",
+			files: url.Values{
+				"files[0].name": {"a.txt"}, "files[0].language": {"plaintext"}, "files[0].content": {"a"},
+				"files[1].name": {"a.txt"}, "files[1].language": {"plaintext"}, "files[1].content": {"b"},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "File 2: the name is already used by another file or by the snippet content",
+		},
+		{
+			name: "Name of the content",
+			files: url.Values{
+				"files[0].name": {"a-bundle.txt"}, "files[0].language": {"plaintext"}, "files[0].content": {"a"},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "File 1: the name is already used by another file or by the snippet content",
+		},
+		{
+			name: "Blank content",
+			files: url.Values{
+				"files[0].name": {"a.txt"}, "files[0].language": {"plaintext"}, "files[0].content": {" "},
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "File 1: the content cannot be blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("Create/"+tt.name, func(t *testing.T) {
+			form := tt.files
+			form.Add("title", "A bundle")
+			form.Add("content", "Some content")
+			form.Add("format", "plain")
+			form.Add("language", "plaintext")
+			form.Add("visibility", "public")
+			form.Add("expires", "never")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, "/snippet/create", form)
+			assert.Equal(t, code, tt.wantCode)
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	validCSRFToken := ts.login(t)
+
+	t.Run("List", func(t *testing.T) {
+		code, _, body := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Deploy script")
+		assert.StringContains(t, body, "Dashboard")
+		assert.StringContains(t, body, "<form action='/account/tokens/revoke/1' method='POST'>")
+	})
+
+	t.Run("Valid submission", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("name", "CI pipeline")
+		form.Add("scope", "write")
+		form.Add("expiresIn", "90")
+		form.Add("csrf_token", validCSRFToken)
+
+		code, headers, body := ts.postForm(t, "/account/tokens", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/account/tokens")
+		assert.Equal(t, strings.Contains(body, "NewToken0003"), false)
+
+		_, _, body = ts.get(t, "/account/tokens")
+		assert.StringContains(t, body, "API token successfully created!")
+		assert.StringContains(t, body, "<pre><code>NewToken0003</code></pre>")
+
+		_, _, body = ts.get(t, "/account/tokens")
+		assert.Equal(t, strings.Contains(body, "NewToken0003"), false)
+	})
+
+	tests := []struct {
+		name      string
+		tokenName string
+		scope     string
+		expiresIn string
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:      "Blank name",
+			tokenName: "",
+			scope:     "read",
+			expiresIn: "30",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field cannot be blank",
+		},
+		{
+			name:      "Invalid scope",
+			tokenName: "CI pipeline",
+			scope:     "admin",
+			expiresIn: "30",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must equal read or write",
+		},
+		{
+			name:      "Invalid expiry",
+			tokenName: "CI pipeline",
+			scope:     "read",
+			expiresIn: "3650",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must equal 7, 30, 90 or 365 days",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.tokenName)
+			form.Add("scope", tt.scope)
+			form.Add("expiresIn", tt.expiresIn)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, _, body := ts.postForm(t, "/account/tokens", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	revokeTests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Revoke own token",
+			urlPath:      "/account/tokens/revoke/2",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/tokens",
+		},
+		{
+			name:     "Revoke unknown token",
+			urlPath:  "/account/tokens/revoke/99",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Revoke invalid ID",
+			urlPath:  "/account/tokens/revoke/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range revokeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
 }
@@ -1,15 +1,25 @@
 package main
 
 import (
+	"archive/zip"
+	"asniki/snippetbox/internal/models"
+	"asniki/snippetbox/internal/validator"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/form/v4"
 	"github.com/justinas/nosurf"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 // serverError writes an error message and stack trace to the errorLog,
@@ -63,10 +73,11 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, status in
 // newTemplateData constructs new templateData
 func (app *application) newTemplateData(r *http.Request) *templateData {
 	return &templateData{
-		CurrentYear:     time.Now().Year(),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
-		IsAuthenticated: app.isAuthenticated(r),
-		CSRFToken:       nosurf.Token(r),
+		CurrentYear:         time.Now().Year(),
+		Flash:               app.sessionManager.PopString(r.Context(), "flash"),
+		IsAuthenticated:     app.isAuthenticated(r),
+		AuthenticatedUserID: app.authenticatedUserID(r),
+		CSRFToken:           nosurf.Token(r),
 	}
 }
 
@@ -100,3 +111,255 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// authenticatedUserID returns the ID of the current authenticated user or 0 for an anonymous request
+func (app *application) authenticatedUserID(r *http.Request) int {
+	id, ok := r.Context().Value(authenticatedUserIDContextKey).(int)
+	if !ok {
+		return 0
+	}
+
+	return id
+}
+
+// contextSetUser returns a copy of the request with the context marking the user as authenticated
+func (app *application) contextSetUser(r *http.Request, id int) *http.Request {
+	ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+	ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
+	return r.WithContext(ctx)
+}
+
+// readInt reads an integer value from the query string. It returns the default value if the key is missing
+// and records an error on the validator if the value cannot be converted to an integer
+func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddFieldError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
+
+// slugRX matches the share slugs generated by the SnippetModel
+var slugRX = regexp.MustCompile(`^[A-Za-z0-9_-]{12}$`)
+
+// ciphertextRX matches the base64 encoded ciphertext of the snippets encrypted in the browser
+var ciphertextRX = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+
+// snippetFromPath fetches the live snippet identified by the {slug} or the {id} path value. It sends
+// the appropriate error response and returns nil if there is no such snippet the current user may see.
+// Private snippets of other users are reported as not found to avoid leaking their existence.
+// Only the snippets created before the share slugs can be looked up by {id}, so that the links shared
+// back then keep working while the newer snippets cannot be enumerated, and unlisted snippets only by their owner
+func (app *application) snippetFromPath(w http.ResponseWriter, r *http.Request) *models.Snippet {
+	var snippet *models.Snippet
+	var err error
+
+	if slug := r.PathValue("slug"); slug != "" {
+		if !validator.Matches(slug, slugRX) {
+			app.notFound(w)
+			return nil
+		}
+
+		snippet, err = app.snippets.GetBySlug(slug)
+	} else {
+		id, convErr := strconv.Atoi(r.PathValue("id"))
+		if convErr != nil || id < 1 {
+			app.notFound(w)
+			return nil
+		}
+
+		snippet, err = app.snippets.Get(id)
+		if err == nil && !snippet.LegacyLink {
+			err = models.ErrNoRecord
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	visible := snippet.VisibleTo(app.authenticatedUserID(r))
+	if r.PathValue("slug") == "" {
+		visible = snippet.VisibleByIDTo(app.authenticatedUserID(r))
+	}
+
+	if !visible {
+		app.notFound(w)
+		return nil
+	}
+
+	return snippet
+}
+
+// readSnippetFromPath works like snippetFromPath for the handlers which send the snippet content to the user.
+// A locked snippet is answered with the unlock form instead. A burn-after-reading snippet requested by anyone other than its owner is deleted as it is read,
+// so only the first of several concurrent readers gets it and the rest are sent 404 Not Found. It is only read by the POST request
+// of the reveal form, so that the GET and HEAD requests of link checkers and previews cannot burn it before the recipient opens it
+func (app *application) readSnippetFromPath(w http.ResponseWriter, r *http.Request) *models.Snippet {
+	snippet := app.snippetFromPath(w, r)
+	if snippet == nil {
+		return nil
+	}
+
+	if app.snippetLocked(r, snippet) {
+		app.renderUnlock(w, r, http.StatusForbidden, snippet, snippetUnlockForm{Next: r.URL.Path})
+		return nil
+	}
+
+	if !snippet.BurnAfterReading || snippet.Owner.ID == app.authenticatedUserID(r) {
+		return snippet
+	}
+
+	if r.Method != http.MethodPost {
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = snippetRevealForm{Action: r.URL.Path}
+		app.render(w, r, http.StatusOK, "reveal.tmpl", data)
+		return nil
+	}
+
+	snippet, err := app.snippets.Burn(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	return snippet
+}
+
+// snippetLocked returns true if the snippet is protected by a password, which the current user
+// has not entered in this session yet. The owner never has to enter the password
+func (app *application) snippetLocked(r *http.Request, s *models.Snippet) bool {
+	if !s.Protected || s.Owner.ID == app.authenticatedUserID(r) {
+		return false
+	}
+
+	unlockedIDs, _ := app.sessionManager.Get(r.Context(), "unlockedSnippetIDs").([]int)
+	return !slices.Contains(unlockedIDs, s.ID)
+}
+
+// renderUnlock renders the form asking for the password of a protected snippet
+func (app *application) renderUnlock(w http.ResponseWriter, r *http.Request, status int, s *models.Snippet, form snippetUnlockForm) {
+	data := app.newTemplateData(r)
+	data.Snippet = s
+	data.Form = form
+	app.render(w, r, status, "unlock.tmpl", data)
+}
+
+// parseTags splits the comma separated tags input into the list of lowercase tags without blanks and duplicates
+func parseTags(input string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(input, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// snippetFileRX matches the names of the additional snippet files, which cannot contain a path
+var snippetFileRX = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// snippetZipArchive bundles the snippet content, named after the snippet, and its additional files in a zip archive
+func snippetZipArchive(s *models.Snippet) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	files := append([]models.SnippetFile{{Name: snippetFilename(s), Content: s.Content}}, s.Files...)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: s.Created})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = fw.Write([]byte(f.Content))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// filenameRX matches the runs of characters which are replaced by a dash in the download file names
+var filenameRX = regexp.MustCompile(`[^a-z0-9]+`)
+
+// snippetFilename derives the download file name from the snippet title and the extension of its format or language
+func snippetFilename(s *models.Snippet) string {
+	name := strings.Trim(filenameRX.ReplaceAllString(strings.ToLower(s.Title), "-"), "-")
+	if len(name) > 50 {
+		name = strings.TrimRight(name[:50], "-")
+	}
+	if name == "" {
+		name = fmt.Sprintf("snippet-%d", s.ID)
+	}
+
+	extension := "txt"
+	switch s.Format {
+	case "markdown":
+		extension = "md"
+	case "code":
+		if ext, ok := languageExtensions[s.Language]; ok {
+			extension = ext
+		}
+	}
+
+	return name + "." + extension
+}
+
+// findRevision returns the revision with the given ID or nil if there is no such revision in the list
+func findRevision(revisions []*models.Revision, id int) *models.Revision {
+	for _, revision := range revisions {
+		if revision.ID == id {
+			return revision
+		}
+	}
+
+	return nil
+}
+
+// unifiedDiff returns the lines of the unified diff between the contents of two revisions
+func unifiedDiff(from, to *models.Revision) ([]string, error) {
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.ReplaceAll(from.Content, "\r\n", "\n")),
+		B:        difflib.SplitLines(strings.ReplaceAll(to.Content, "\r\n", "\n")),
+		FromFile: fmt.Sprintf("Revision #%d", from.ID),
+		FromDate: humanDate(from.Created),
+		ToFile:   fmt.Sprintf("Revision #%d", to.ID),
+		ToDate:   humanDate(to.Created),
+		Context:  3,
+	}
+
+	text, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	if text == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"asniki/snippetbox/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestSnippetFilename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		snippet models.Snippet
+		want    string
+	}{
+		{
+			name:    "Plain text",
+			snippet: models.Snippet{ID: 1, Title: "An old silent pond", Format: "plain"},
+			want:    "an-old-silent-pond.txt",
+		},
+		{
+			name:    "Markdown",
+			snippet: models.Snippet{ID: 1, Title: "Release notes", Format: "markdown"},
+			want:    "release-notes.md",
+		},
+		{
+			name:    "Code",
+			snippet: models.Snippet{ID: 1, Title: "main.go", Format: "code", Language: "go"},
+			want:    "main-go.go",
+		},
+		{
+			name:    "Punctuation only",
+			snippet: models.Snippet{ID: 7, Title: "¿¡...!?", Format: "plain"},
+			want:    "snippet-7.txt",
+		},
+		{
+			name:    "Long title",
+			snippet: models.Snippet{ID: 1, Title: strings.Repeat("a", 49) + " " + strings.Repeat("b", 10), Format: "plain"},
+			want:    strings.Repeat("a", 49) + ".txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, snippetFilename(&tt.snippet), tt.want)
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Empty",
+			input: "",
+			want:  "",
+		},
+		{
+			name:  "Single",
+			input: "go",
+			want:  "go",
+		},
+		{
+			name:  "Spaces and case",
+			input: " Go ,  Web-Dev ",
+			want:  "go|web-dev",
+		},
+		{
+			name:  "Blanks and duplicates",
+			input: "go,, ,GO,sql,",
+			want:  "go|sql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, strings.Join(parseTags(tt.input), "|"), tt.want)
+		})
+	}
+}
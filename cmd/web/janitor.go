@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runJanitor purges the expired snippets every interval until the context is cancelled.
+// Snippets are only purged once they have been expired for longer than the grace period,
+// so that their owners can still find them in their snippet listing for a while
+func (app *application) runJanitor(ctx context.Context, interval time.Duration, batchSize int, grace time.Duration) {
+	app.logger.Info("starting janitor", "interval", interval.String(), "batchSize", batchSize, "grace", grace.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			app.logger.Info("stopped janitor")
+			return
+		case <-ticker.C:
+			n, err := app.purgeExpired(ctx, time.Now().Add(-grace), batchSize)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+			if n > 0 {
+				app.logger.Info("purged expired snippets", "count", n)
+			}
+		}
+	}
+}
+
+// purgeExpired deletes the snippets which expired before the given time in batches of batchSize,
+// so that a large backlog does not lock the snippets table for long. It returns the number of
+// snippets removed, stopping early if the context is cancelled
+func (app *application) purgeExpired(ctx context.Context, before time.Time, batchSize int) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := app.snippets.DeleteExpired(before, batchSize)
+		total += n
+		if err != nil || n < batchSize {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"asniki/snippetbox/internal/models/mocks"
+	"context"
+	"testing"
+	"time"
+)
+
+// expiredSnippetModel removes its expired snippets in batches
+type expiredSnippetModel struct {
+	mocks.SnippetModel
+	expired int
+	calls   int
+}
+
+func (m *expiredSnippetModel) DeleteExpired(before time.Time, limit int) (int, error) {
+	m.calls++
+	n := min(m.expired, limit)
+	m.expired -= n
+	return n, nil
+}
+
+func TestPurgeExpired(t *testing.T) {
+	tests := []struct {
+		name      string
+		expired   int
+		batchSize int
+		wantCalls int
+	}{
+		{
+			name:      "Nothing expired",
+			expired:   0,
+			batchSize: 10,
+			wantCalls: 1,
+		},
+		{
+			name:      "Single batch",
+			expired:   7,
+			batchSize: 10,
+			wantCalls: 1,
+		},
+		{
+			name:      "Several batches",
+			expired:   25,
+			batchSize: 10,
+			wantCalls: 3,
+		},
+		{
+			name:      "Exact batches",
+			expired:   20,
+			batchSize: 10,
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			snippets := &expiredSnippetModel{expired: tt.expired}
+			app.snippets = snippets
+
+			n, err := app.purgeExpired(context.Background(), time.Now(), tt.batchSize)
+			assert.NilError(t, err)
+			assert.Equal(t, n, tt.expired)
+			assert.Equal(t, snippets.calls, tt.wantCalls)
+		})
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		app := newTestApplication(t)
+		snippets := &expiredSnippetModel{expired: 25}
+		app.snippets = snippets
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		n, err := app.purgeExpired(ctx, time.Now(), 10)
+		assert.NilError(t, err)
+		assert.Equal(t, n, 0)
+		assert.Equal(t, snippets.calls, 0)
+	})
+}
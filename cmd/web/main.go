@@ -2,8 +2,10 @@ package main
 
 import (
 	"asniki/snippetbox/internal/models"
+	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
@@ -11,7 +13,10 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/alexedwards/scs/mysqlstore"
@@ -27,10 +32,13 @@ import (
 type application struct {
 	logger         *slog.Logger
 	snippets       models.SnippetModelInterface
+	revisions      models.RevisionModelInterface
 	users          models.UserModelInterface
+	tokens         models.TokenModelInterface
 	templateCache  map[string]*template.Template
 	formDecoder    *form.Decoder
 	sessionManager *scs.SessionManager
+	unlockLimiter  *attemptLimiter
 	debug          bool
 }
 
@@ -78,17 +86,28 @@ func main() {
 	defaultDsn := os.Getenv("DSN")
 
 	var (
-		addr      string
-		staticDir string
-		dsn       string
-		debugFlag bool
+		addr             string
+		staticDir        string
+		dsn              string
+		debugFlag        bool
+		janitorInterval  time.Duration
+		janitorBatchSize int
+		janitorGrace     time.Duration
 	)
 	flag.StringVar(&addr, "addr", ":4000", "HTTP network address")
 	flag.StringVar(&staticDir, "static-dir", "./ui/static", "Path to static assets")
 	flag.StringVar(&dsn, "dsn", defaultDsn, "MySQL data source name")
 	flag.BoolVar(&debugFlag, "debug", false, "Enable debug mode")
+	flag.DurationVar(&janitorInterval, "janitor-interval", time.Hour, "How often to purge the expired snippets (0 disables the janitor)")
+	flag.IntVar(&janitorBatchSize, "janitor-batch-size", 1000, "Maximum number of expired snippets to delete per statement")
+	flag.DurationVar(&janitorGrace, "janitor-grace", 7*24*time.Hour, "How long to keep the expired snippets before purging them")
 	flag.Parse()
 
+	if janitorBatchSize < 1 {
+		slogLogger.Error("janitor-batch-size must be greater than zero")
+		os.Exit(1)
+	}
+
 	db, err := openDB(dsn)
 	if err != nil {
 		slogLogger.Error(err.Error())
@@ -111,10 +130,13 @@ func main() {
 	app := &application{
 		logger:         slogLogger,
 		snippets:       &models.SnippetModel{DB: db},
+		revisions:      &models.RevisionModel{DB: db},
 		users:          &models.UserModel{DB: db},
+		tokens:         &models.TokenModel{DB: db},
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
+		unlockLimiter:  newAttemptLimiter(5, 15*time.Minute),
 		debug:          debugFlag,
 	}
 
@@ -135,7 +157,44 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	if janitorInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.runJanitor(ctx, janitorInterval, janitorBatchSize, janitorGrace)
+		}()
+	}
+
+	shutdownError := make(chan error)
+	go func() {
+		<-ctx.Done()
+		app.logger.Info("shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		shutdownError <- srv.Shutdown(shutdownCtx)
+	}()
+
 	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
-	app.logger.Error(err.Error())
-	os.Exit(1)
+	if !errors.Is(err, http.ErrServerClosed) {
+		app.logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = <-shutdownError
+	if err != nil {
+		app.logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// let the janitor finish its current batch before closing the database
+	wg.Wait()
+	db.Close()
+
+	app.logger.Info("stopped server")
 }
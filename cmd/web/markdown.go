@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdownRenderer converts markdown to HTML. The unsafe mode is left disabled,
+// so raw HTML in the source is omitted and links with dangerous URLs are dropped
+var markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// markdownPolicy sanitizes the rendered markdown before it is trusted by html/template,
+// so that nothing the renderer lets through can inject scripts, styles or event handlers
+var markdownPolicy = bluemonday.UGCPolicy()
+
+// renderMarkdown returns the markdown source as sanitized HTML. It falls back to
+// the escaped plain text if the source cannot be converted
+func renderMarkdown(source string) template.HTML {
+	buf := new(bytes.Buffer)
+	err := markdownRenderer.Convert([]byte(source), buf)
+	if err != nil {
+		return plainCode(source)
+	}
+
+	return template.HTML(markdownPolicy.SanitizeBytes(buf.Bytes()))
+}
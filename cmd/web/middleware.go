@@ -1,10 +1,14 @@
 package main
 
 import (
+	"asniki/snippetbox/internal/models"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/justinas/alice"
 	"github.com/justinas/nosurf"
 )
 
@@ -95,10 +99,78 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		}
 
 		if exists {
-			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
-			r = r.WithContext(ctx)
+			r = app.contextSetUser(r, id)
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticateToken is the counterpart of authenticate for the API: it accepts the personal API token
+// from the "Authorization: Bearer" header and updates the request context for its user the same way the session does.
+// The token is sent with every request explicitly, so the API needs no CSRF protection
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, plaintext, ok := strings.Cut(authorizationHeader, " ")
+		if !ok || scheme != "Bearer" || plaintext == "" {
+			app.invalidTokenResponse(w, r)
+			return
+		}
+
+		token, err := app.tokens.GetByPlaintext(plaintext)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.invalidTokenResponse(w, r)
+			} else {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		exists, err := app.users.Exists(token.UserID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !exists {
+			app.invalidTokenResponse(w, r)
+			return
+		}
+
+		r = app.contextSetUser(r, token.UserID)
+		r = r.WithContext(context.WithValue(r.Context(), tokenContextKey, token))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireTokenScope restricts access to the API for an unauthenticated client
+// and for the tokens which do not grant the scope
+func (app *application) requireTokenScope(scope string) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := r.Context().Value(tokenContextKey).(*models.Token)
+			if !ok {
+				app.authenticationRequiredResponse(w, r)
+				return
+			}
+
+			if !token.Allows(scope) {
+				app.notPermittedResponse(w, r, fmt.Sprintf("this token does not have the %q scope", scope))
+				return
+			}
+
+			w.Header().Add("Cache-Control", "no-store")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openAPIMethods lists the operations of a path item of the OpenAPI document
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// getOpenAPISpec fetches and decodes the OpenAPI document served by the test server
+func getOpenAPISpec(t *testing.T, ts *testServer) map[string]any {
+	code, _, body := ts.doJSON(t, http.MethodGet, "/api/v1/openapi.json", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("openapi.json returned status %d", code)
+	}
+
+	var spec map[string]any
+	err := json.Unmarshal([]byte(body), &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return spec
+}
+
+// resolveRef returns the object a local reference such as "#/components/schemas/Snippet" points to
+func resolveRef(t *testing.T, spec map[string]any, ref string) map[string]any {
+	node := spec
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		next, ok := node[key].(map[string]any)
+		if !ok {
+			t.Fatalf("cannot resolve %s", ref)
+		}
+		node = next
+	}
+	return node
+}
+
+// deref resolves the object if it is a reference
+func deref(t *testing.T, spec map[string]any, node map[string]any) map[string]any {
+	if ref, ok := node["$ref"].(string); ok {
+		return deref(t, spec, resolveRef(t, spec, ref))
+	}
+	return node
+}
+
+// checkSchema validates the decoded JSON value against the schema of the OpenAPI document
+// and returns the violations found
+func checkSchema(t *testing.T, spec, schema map[string]any, value any, at string) []string {
+	schema = deref(t, spec, schema)
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return nil
+		}
+		return []string{at + ": must not be null"}
+	}
+
+	var errs []string
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{at + ": must be an object"}
+		}
+
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]any)
+		for name, v := range obj {
+			if property, ok := properties[name].(map[string]any); ok {
+				errs = append(errs, checkSchema(t, spec, property, v, at+"."+name)...)
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Sprintf("%s: undocumented property %q", at, name))
+				}
+			case map[string]any:
+				errs = append(errs, checkSchema(t, spec, additional, v, at+"."+name)...)
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return []string{at + ": must be an array"}
+		}
+
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, v := range arr {
+				errs = append(errs, checkSchema(t, spec, items, v, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{at + ": must be a string"}
+		}
+
+		if schema["format"] == "date-time" {
+			_, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				errs = append(errs, at+": must be an RFC 3339 date and time")
+			}
+		}
+	case "integer":
+		f, ok := value.(float64)
+		if !ok || f != math.Trunc(f) {
+			return []string{at + ": must be an integer"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{at + ": must be a boolean"}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", at, value, enum))
+	}
+
+	return errs
+}
+
+// specPath returns the path of the OpenAPI document matching the request path, such as /snippets/{id}
+// for /api/v1/snippets/1, or an empty string if there is none
+func specPath(spec map[string]any, urlPath string) string {
+	urlPath = strings.TrimPrefix(strings.SplitN(urlPath, "?", 2)[0], "/api/v1")
+	segments := strings.Split(urlPath, "/")
+
+	paths := spec["paths"].(map[string]any)
+	for path := range paths {
+		templateSegments := strings.Split(path, "/")
+		if len(templateSegments) != len(segments) {
+			continue
+		}
+
+		matches := true
+		for i, segment := range templateSegments {
+			if segment != segments[i] && !strings.HasPrefix(segment, "{") {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	spec := getOpenAPISpec(t, ts)
+	assert.Equal(t, spec["openapi"].(string), "3.0.3")
+
+	servers := spec["servers"].([]any)
+	basePath := servers[0].(map[string]any)["url"].(string)
+	paths := spec["paths"].(map[string]any)
+
+	routes := []string{}
+	for _, route := range app.apiRoutes() {
+		method, path, _ := strings.Cut(route.pattern, " ")
+		operation := strings.ToLower(method) + " " + strings.TrimPrefix(path, basePath)
+		routes = append(routes, operation)
+
+		t.Run(route.pattern, func(t *testing.T) {
+			item, ok := paths[strings.TrimPrefix(path, basePath)].(map[string]any)
+			if !ok {
+				t.Fatalf("path %s is not documented", path)
+			}
+
+			if _, ok := item[strings.ToLower(method)]; !ok {
+				t.Fatalf("method %s of path %s is not documented", method, path)
+			}
+		})
+	}
+
+	for path, item := range paths {
+		for _, method := range openAPIMethods {
+			if _, ok := item.(map[string]any)[method]; !ok {
+				continue
+			}
+
+			if !slices.Contains(routes, method+" "+path) {
+				t.Errorf("documented operation %s %s is not registered", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	spec := getOpenAPISpec(t, ts)
+
+	tests := []struct {
+		method   string
+		urlPath  string
+		token    string
+		body     string
+		wantCode int
+	}{
+		{http.MethodGet, "/api/v1/openapi.json", "", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets", "", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets?mine=true", "ValidBobToken", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets?mine=true", "", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/snippets?page=0", "", "", http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/snippets", "ValidBobToken", `{"title": "O snail", "content": "O snail", "tags": ["haiku"], "files": [{"name": "snail.txt", "content": "Climb Mount Fuji"}]}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/snippets", "ValidBobToken", `{"title": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/snippets", "", `{"title": "O snail", "content": "O snail"}`, http.StatusUnauthorized},
+		{http.MethodPost, "/api/v1/snippets", "ReadOnlyBobToken", `{"title": "O snail", "content": "O snail"}`, http.StatusForbidden},
+		{http.MethodPost, "/api/v1/snippets", "ValidBobToken", `{"title": "", "content": ""}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/snippets/1", "", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets/3", "", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets/4", "ValidBobToken", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets/5", "ReadOnlyBobToken", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets/7", "ValidBobToken", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/snippets/1", "InvalidToken", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/snippets/6", "", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/snippets/2", "", "", http.StatusNotFound},
+		{http.MethodPatch, "/api/v1/snippets/1", "ValidBobToken", `{"title": "A new silent pond", "tags": []}`, http.StatusOK},
+		{http.MethodPatch, "/api/v1/snippets/1", "ValidBobToken", `{"password": "open sesame"}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/snippets/1", "", `{"title": "A new silent pond"}`, http.StatusUnauthorized},
+		{http.MethodPatch, "/api/v1/snippets/3", "ValidBobToken", `{"title": "A new silent pond"}`, http.StatusForbidden},
+		{http.MethodPatch, "/api/v1/snippets/2", "ValidBobToken", `{"title": "A new silent pond"}`, http.StatusNotFound},
+		{http.MethodPatch, "/api/v1/snippets/1", "ValidBobToken", `{"format": "html"}`, http.StatusUnprocessableEntity},
+		{http.MethodDelete, "/api/v1/snippets/1", "ValidBobToken", "", http.StatusNoContent},
+		{http.MethodDelete, "/api/v1/snippets/1", "", "", http.StatusUnauthorized},
+		{http.MethodDelete, "/api/v1/snippets/3", "ValidBobToken", "", http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/snippets/2", "ValidBobToken", "", http.StatusNotFound},
+	}
+
+	covered := []string{}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s %d", tt.method, tt.urlPath, tt.wantCode), func(t *testing.T) {
+			code, header, body := ts.doJSON(t, tt.method, tt.urlPath, tt.token, tt.body)
+			assert.Equal(t, code, tt.wantCode)
+
+			path := specPath(spec, tt.urlPath)
+			if path == "" {
+				t.Fatalf("%s is not documented", tt.urlPath)
+			}
+
+			method := strings.ToLower(tt.method)
+			operation, ok := spec["paths"].(map[string]any)[path].(map[string]any)[method].(map[string]any)
+			if !ok {
+				t.Fatalf("%s %s is not documented", method, path)
+			}
+			covered = append(covered, method+" "+path)
+
+			response, ok := operation["responses"].(map[string]any)[fmt.Sprint(code)].(map[string]any)
+			if !ok {
+				t.Fatalf("status %d of %s %s is not documented", code, method, path)
+			}
+			response = deref(t, spec, response)
+
+			content, ok := response["content"].(map[string]any)
+			if !ok {
+				assert.Equal(t, body, "")
+				return
+			}
+
+			assert.Equal(t, header.Get("Content-Type"), "application/json")
+
+			var value any
+			err := json.Unmarshal([]byte(body), &value)
+			assert.NilError(t, err)
+
+			schema := content["application/json"].(map[string]any)["schema"].(map[string]any)
+			for _, violation := range checkSchema(t, spec, schema, value, "response") {
+				t.Error(violation)
+			}
+		})
+	}
+
+	for path, item := range spec["paths"].(map[string]any) {
+		for _, method := range openAPIMethods {
+			if _, ok := item.(map[string]any)[method]; ok && !slices.Contains(covered, method+" "+path) {
+				t.Errorf("no response of %s %s was checked", method, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// attemptLimiter limits the number of failed attempts per key within a sliding time window.
+// Every attempt is counted as soon as it starts and only refunded once it has succeeded,
+// so that concurrent attempts cannot all get past the limit while the slow check is running
+type attemptLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	attempts map[int][]time.Time
+}
+
+// newAttemptLimiter returns an attemptLimiter allowing up to max failed attempts per key within the window
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		max:      max,
+		window:   window,
+		attempts: make(map[int][]time.Time),
+	}
+}
+
+// Reserve records an attempt for the key and returns true if it is permitted,
+// nothing is recorded for an attempt which is not permitted
+func (l *attemptLimiter) Reserve(key int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	attempts := l.prune(key, now)
+	if len(attempts) >= l.max {
+		return false
+	}
+
+	l.attempts[key] = append(attempts, now)
+	return true
+}
+
+// Refund gives back the latest attempt reserved for the key, for the attempts which have not failed
+func (l *attemptLimiter) Refund(key int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempts := l.prune(key, time.Now())
+	if len(attempts) == 0 {
+		return
+	}
+
+	if len(attempts) == 1 {
+		delete(l.attempts, key)
+		return
+	}
+
+	l.attempts[key] = attempts[:len(attempts)-1]
+}
+
+// prune drops the attempts of the key which are older than the window and returns the remaining ones,
+// the caller must hold the lock
+func (l *attemptLimiter) prune(key int, now time.Time) []time.Time {
+	attempts := l.attempts[key]
+	for len(attempts) > 0 && now.Sub(attempts[0]) >= l.window {
+		attempts = attempts[1:]
+	}
+
+	if len(attempts) == 0 {
+		delete(l.attempts, key)
+		return nil
+	}
+
+	l.attempts[key] = attempts
+	return attempts
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAttemptLimiter(t *testing.T) {
+	t.Run("Concurrent attempts", func(t *testing.T) {
+		l := newAttemptLimiter(5, time.Minute)
+
+		var wg sync.WaitGroup
+		var permitted atomic.Int32
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if l.Reserve(1) {
+					permitted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, permitted.Load(), int32(5))
+		assert.Equal(t, l.Reserve(2), true)
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		l := newAttemptLimiter(2, time.Minute)
+
+		assert.Equal(t, l.Reserve(1), true)
+		assert.Equal(t, l.Reserve(1), true)
+		assert.Equal(t, l.Reserve(1), false)
+
+		l.Refund(1)
+		assert.Equal(t, l.Reserve(1), true)
+		assert.Equal(t, l.Reserve(1), false)
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		l := newAttemptLimiter(1, time.Millisecond)
+
+		assert.Equal(t, l.Reserve(1), true)
+		time.Sleep(2 * time.Millisecond)
+		assert.Equal(t, l.Reserve(1), true)
+	})
+}
@@ -19,7 +19,20 @@ func (app *application) routes() http.Handler {
 	dynamic := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
 
 	mux.Handle("GET /{$}", dynamic.ThenFunc(app.home))
+	mux.Handle("GET /s/{slug}", dynamic.ThenFunc(app.snippetView))
+	mux.Handle("POST /s/{slug}", dynamic.ThenFunc(app.snippetView))
 	mux.Handle("GET /snippet/view/{id}", dynamic.ThenFunc(app.snippetView))
+	mux.Handle("POST /snippet/view/{id}", dynamic.ThenFunc(app.snippetView))
+	mux.Handle("GET /snippet/raw/{slug}", dynamic.ThenFunc(app.snippetRaw))
+	mux.Handle("POST /snippet/raw/{slug}", dynamic.ThenFunc(app.snippetRaw))
+	mux.Handle("GET /snippet/download/{slug}", dynamic.ThenFunc(app.snippetDownload))
+	mux.Handle("POST /snippet/download/{slug}", dynamic.ThenFunc(app.snippetDownload))
+	mux.Handle("GET /snippet/zip/{slug}", dynamic.ThenFunc(app.snippetZip))
+	mux.Handle("POST /snippet/zip/{slug}", dynamic.ThenFunc(app.snippetZip))
+	mux.Handle("POST /snippet/unlock/{slug}", dynamic.ThenFunc(app.snippetUnlockPost))
+	mux.Handle("GET /snippets", dynamic.ThenFunc(app.snippetArchive))
+	mux.Handle("GET /search", dynamic.ThenFunc(app.search))
+	mux.Handle("GET /tags/{tag}", dynamic.ThenFunc(app.tagView))
 	mux.Handle("GET /user/signup", dynamic.ThenFunc(app.userSignup))
 	mux.Handle("POST /user/signup", dynamic.ThenFunc(app.userSignupPost))
 	mux.Handle("GET /user/login", dynamic.ThenFunc(app.userLogin))
@@ -30,11 +43,47 @@ func (app *application) routes() http.Handler {
 
 	mux.Handle("GET /snippet/create", protected.ThenFunc(app.snippetCreate))
 	mux.Handle("POST /snippet/create", protected.ThenFunc(app.snippetCreatePost))
+	mux.Handle("GET /snippet/edit/{slug}", protected.ThenFunc(app.snippetEdit))
+	mux.Handle("POST /snippet/edit/{slug}", protected.ThenFunc(app.snippetEditPost))
+	mux.Handle("GET /snippet/history/{slug}", protected.ThenFunc(app.snippetHistory))
+	mux.Handle("POST /snippet/fork/{slug}", protected.ThenFunc(app.snippetForkPost))
+	mux.Handle("POST /snippet/delete/{slug}", protected.ThenFunc(app.snippetDeletePost))
+	mux.Handle("POST /snippet/expire/{slug}", protected.ThenFunc(app.snippetExpirePost))
 	mux.Handle("POST /user/logout", protected.ThenFunc(app.userLogoutPost))
 	mux.Handle("GET /account/view", protected.ThenFunc(app.accountView))
+	mux.Handle("GET /account/snippets", protected.ThenFunc(app.accountSnippets))
 	mux.Handle("GET /account/password/update", protected.ThenFunc(app.accountPasswordUpdate))
 	mux.Handle("POST /account/password/update", protected.ThenFunc(app.accountPasswordUpdatePost))
+	mux.Handle("GET /account/tokens", protected.ThenFunc(app.accountTokens))
+	mux.Handle("POST /account/tokens", protected.ThenFunc(app.accountTokensPost))
+	mux.Handle("POST /account/tokens/revoke/{id}", protected.ThenFunc(app.accountTokenRevokePost))
+
+	for _, route := range app.apiRoutes() {
+		mux.Handle(route.pattern, route.handler)
+	}
 
 	standard := alice.New(app.recoverPanic, app.logRequest, commonHeaders)
 	return standard.Then(mux)
 }
+
+// apiRoute is an endpoint of the JSON API, the pattern includes the method
+type apiRoute struct {
+	pattern string
+	handler http.Handler
+}
+
+// apiRoutes returns the endpoints of the JSON API, which must all be described by the OpenAPI document
+func (app *application) apiRoutes() []apiRoute {
+	// the API authenticates with bearer tokens instead of the session cookie, so it needs no CSRF protection
+	api := alice.New(app.authenticateToken)
+	apiWrite := api.Append(app.requireTokenScope("write"))
+
+	return []apiRoute{
+		{"GET /api/v1/openapi.json", http.HandlerFunc(app.apiOpenAPI)},
+		{"GET /api/v1/snippets", api.ThenFunc(app.apiSnippetList)},
+		{"GET /api/v1/snippets/{id}", api.ThenFunc(app.apiSnippetGet)},
+		{"POST /api/v1/snippets", apiWrite.ThenFunc(app.apiSnippetCreate)},
+		{"PATCH /api/v1/snippets/{id}", apiWrite.ThenFunc(app.apiSnippetUpdate)},
+		{"DELETE /api/v1/snippets/{id}", apiWrite.ThenFunc(app.apiSnippetDelete)},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// supportedFormats lists the ways a snippet content can be rendered: as plain text,
+// as markdown or as code highlighted for the snippet language
+var supportedFormats = []string{"plain", "markdown", "code"}
+
+// supportedLanguages lists the languages a snippet can be highlighted as, the values are chroma lexer names
+var supportedLanguages = []string{
+	"plaintext", "bash", "c", "cpp", "css", "dockerfile", "go", "html", "java",
+	"javascript", "json", "python", "ruby", "rust", "sql", "typescript", "yaml",
+}
+
+// languageExtensions maps the supported languages to the file extensions used for the snippet downloads
+var languageExtensions = map[string]string{
+	"plaintext":  "txt",
+	"bash":       "sh",
+	"c":          "c",
+	"cpp":        "cpp",
+	"css":        "css",
+	"dockerfile": "dockerfile",
+	"go":         "go",
+	"html":       "html",
+	"java":       "java",
+	"javascript": "js",
+	"json":       "json",
+	"python":     "py",
+	"ruby":       "rb",
+	"rust":       "rs",
+	"sql":        "sql",
+	"typescript": "ts",
+	"yaml":       "yaml",
+}
+
+// highlightFormatter emits CSS classes instead of inline styles, which the Content-Security-Policy doesn't allow.
+// The classes are defined in ui/static/css/chroma.css
+var highlightFormatter = html.New(html.WithClasses(true))
+
+// highlightCode returns the code highlighted as the given language. It falls back to
+// the escaped plain text if the code cannot be highlighted
+func highlightCode(code, language string) template.HTML {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return plainCode(code)
+	}
+
+	buf := new(bytes.Buffer)
+	err = highlightFormatter.Format(buf, styles.Fallback, iterator)
+	if err != nil {
+		return plainCode(code)
+	}
+
+	return template.HTML(buf.String())
+}
+
+// plainCode returns the escaped code wrapped in the preformatted block
+func plainCode(code string) template.HTML {
+	return template.HTML("<pre><code>" + template.HTMLEscapeString(code) + "</code></pre>")
+}
@@ -6,19 +6,32 @@ import (
 	"html/template"
 	"io/fs"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // templateData holds dynamic data to pass to the HTML templates
 type templateData struct {
-	CurrentYear     int
-	Snippet         *models.Snippet
-	Snippets        []*models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
-	User            models.User
+	CurrentYear         int
+	Snippet             *models.Snippet
+	Parent              *models.Snippet
+	Snippets            []*models.Snippet
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	CSRFToken           string
+	User                models.User
+	Metadata            models.Metadata
+	Sort                string
+	Query               string
+	Tag                 string
+	Revisions           []*models.Revision
+	Tokens              []*models.Token
+	NewToken            string
+	Diff                []string
 }
 
 // humanDate returns a nicely formatted string representation of a time.Time object
@@ -29,8 +42,66 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04:05")
 }
 
+// excerpt shortens the text to at most n characters
+func excerpt(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n]) + "…"
+}
+
+// highlight escapes the text and wraps every case-insensitive occurrence of the query words in a <mark> element
+func highlight(text, query string) template.HTML {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	rx := regexp.MustCompile("(?i)" + strings.Join(words, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range rx.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+// diffClass returns the CSS class for a line of a unified diff
+func diffClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return "file"
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	default:
+		return ""
+	}
+}
+
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"humanDate":      humanDate,
+	"excerpt":        excerpt,
+	"diffClass":      diffClass,
+	"highlight":      highlight,
+	"highlightCode":  highlightCode,
+	"renderMarkdown": renderMarkdown,
+	"formats":        func() []string { return supportedFormats },
+	"languages":      func() []string { return supportedLanguages },
+	"visibilities":   func() []string { return supportedVisibilities },
 }
 
 // newTemplateCache initializes a new template cache
@@ -2,6 +2,7 @@ package main
 
 import (
 	"asniki/snippetbox/internal/assert"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,3 +39,134 @@ func TestHumanDate2(t *testing.T) {
 		})
 	}
 }
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{
+			name:  "Single word",
+			text:  "An old silent pond",
+			query: "pond",
+			want:  "An old silent <mark>pond</mark>",
+		},
+		{
+			name:  "Case insensitive",
+			text:  "An old silent pond",
+			query: "OLD Pond",
+			want:  "An <mark>old</mark> silent <mark>pond</mark>",
+		},
+		{
+			name:  "Escaped text",
+			text:  "<b>pond</b>",
+			query: "pond",
+			want:  "&lt;b&gt;<mark>pond</mark>&lt;/b&gt;",
+		},
+		{
+			name:  "Regexp metacharacters",
+			text:  "a.b axb",
+			query: "a.b",
+			want:  "<mark>a.b</mark> axb",
+		},
+		{
+			name:  "Empty query",
+			text:  "An old silent pond",
+			query: "",
+			want:  "An old silent pond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(highlight(tt.text, tt.query)), tt.want)
+		})
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		code     string
+		language string
+		want     string
+	}{
+		{
+			name:     "Go keyword",
+			code:     "package main",
+			language: "go",
+			want:     `<span class="kn">package</span>`,
+		},
+		{
+			name:     "Escaped markup",
+			code:     "<script>alert(1)</script>",
+			language: "plaintext",
+			want:     "&lt;script&gt;alert(1)&lt;/script&gt;",
+		},
+		{
+			name:     "Unknown language",
+			code:     "a < b",
+			language: "unknown",
+			want:     "a &lt; b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(highlightCode(tt.code, tt.language))
+			assert.StringContains(t, got, tt.want)
+			assert.Equal(t, strings.Contains(got, "style="), false)
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		source   string
+		want     string
+		wantNone string
+	}{
+		{
+			name:   "Emphasis",
+			source: "An *old* silent pond",
+			want:   "<p>An <em>old</em> silent pond</p>",
+		},
+		{
+			name:     "Raw HTML",
+			source:   "<script>alert(1)</script>",
+			wantNone: "<script>",
+		},
+		{
+			name:     "Javascript link",
+			source:   "[pond](javascript:alert(1))",
+			wantNone: "javascript:",
+		},
+		{
+			name:     "Event handler",
+			source:   "<img src=x onerror=alert(1)>",
+			wantNone: "onerror",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(renderMarkdown(tt.source))
+
+			if tt.want != "" {
+				assert.StringContains(t, got, tt.want)
+			}
+			if tt.wantNone != "" {
+				assert.Equal(t, strings.Contains(got, tt.wantNone), false)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,11 +37,14 @@ func newTestApplication(t *testing.T) *application {
 
 	return &application{
 		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
-		snippets:       &mocks.SnippetModel{}, // use the mock
-		users:          &mocks.UserModel{},    // use the mock
+		snippets:       &mocks.SnippetModel{},  // use the mock
+		revisions:      &mocks.RevisionModel{}, // use the mock
+		users:          &mocks.UserModel{},     // use the mock
+		tokens:         &mocks.TokenModel{},    // use the mock
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
+		unlockLimiter:  newAttemptLimiter(5, 15*time.Minute),
 	}
 }
 
@@ -137,3 +141,51 @@ func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (i
 
 	return rs.StatusCode, rs.Header, string(body)
 }
+
+// login signs in the mocked user through the login form and returns the CSRF token
+// which can be used for the subsequent POST requests
+func (ts *testServer) login(t *testing.T) string {
+	_, _, body := ts.get(t, "/user/login")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("email", "bob@example.com")
+	form.Add("password", "validPa$$word")
+	form.Add("csrf_token", validCSRFToken)
+
+	code, _, _ := ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login failed with status %d", code)
+	}
+
+	return validCSRFToken
+}
+
+// doJSON sends an API request with the JSON body (if any) and the bearer token (if any) to the test server,
+// and returns the response status code, headers and body
+func (ts *testServer) doJSON(t *testing.T, method, urlPath, token, body string) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+urlPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rs.Body.Close()
+	respBody, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(respBody))
+}
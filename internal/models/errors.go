@@ -11,4 +11,7 @@ var (
 
 	// ErrDuplicateEmail is returned when user tries to signup with an email address that's already in use
 	ErrDuplicateEmail = errors.New("models: duplicate email")
+
+	// ErrAccountDisabled is returned when a user whose account has been disabled tries to login
+	ErrAccountDisabled = errors.New("models: account disabled")
 )
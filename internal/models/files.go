@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// SnippetFile holds one of the additional named files bundled with a snippet
+type SnippetFile struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// insertFiles saves the additional files of a new snippet within the transaction, in the given order
+func insertFiles(tx *sql.Tx, snippetID int, files []SnippetFile) error {
+	stmt := "INSERT INTO snippet_files (snippet_id, name, language, content) VALUES(?, ?, ?, ?)"
+
+	for _, f := range files {
+		_, err := tx.Exec(stmt, snippetID, f.Name, f.Language, f.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listFiles returns the additional files of the snippet in the order they were added
+func listFiles(q queryer, snippetID int) ([]SnippetFile, error) {
+	stmt := "SELECT id, name, language, content FROM snippet_files WHERE snippet_id = ? ORDER BY id ASC"
+
+	rows, err := q.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []SnippetFile
+	for rows.Next() {
+		var f SnippetFile
+		err = rows.Scan(&f.ID, &f.Name, &f.Language, &f.Content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
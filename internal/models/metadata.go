@@ -0,0 +1,46 @@
+package models
+
+// Metadata holds the pagination details for a page of records
+type Metadata struct {
+	CurrentPage  int `json:"current_page"`
+	PageSize     int `json:"page_size"`
+	LastPage     int `json:"last_page"`
+	TotalRecords int `json:"total_records"`
+	// FirstID and LastID hold the IDs of the first and the last record on a keyset paginated page
+	FirstID int `json:"first_id"`
+	LastID  int `json:"last_id"`
+}
+
+// calculateMetadata calculates the pagination details for the given total number of records
+func calculateMetadata(totalRecords, page, pageSize int) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		LastPage:     (totalRecords + pageSize - 1) / pageSize,
+		TotalRecords: totalRecords,
+	}
+}
+
+// HasPrevious returns true if there is a page before the current one
+func (m Metadata) HasPrevious() bool {
+	return m.CurrentPage > 1
+}
+
+// HasNext returns true if there is a page after the current one
+func (m Metadata) HasNext() bool {
+	return m.CurrentPage < m.LastPage
+}
+
+// PreviousPage returns the number of the page before the current one
+func (m Metadata) PreviousPage() int {
+	return m.CurrentPage - 1
+}
+
+// NextPage returns the number of the page after the current one
+func (m Metadata) NextPage() int {
+	return m.CurrentPage + 1
+}
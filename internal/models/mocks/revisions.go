@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"asniki/snippetbox/internal/models"
+	"time"
+)
+
+// RevisionModel mocks models.RevisionModel
+type RevisionModel struct{}
+
+// List mocks models.RevisionModel.List
+func (m *RevisionModel) List(snippetID int) ([]*models.Revision, error) {
+	if snippetID != mockSnippet.ID {
+		return []*models.Revision{}, nil
+	}
+
+	return []*models.Revision{
+		{
+			ID:        1,
+			SnippetID: mockSnippet.ID,
+			Title:     "An old pond",
+			Content:   "An old pond...\nA frog jumps in",
+			Author:    mockSnippet.Owner,
+			Created:   time.Now(),
+		},
+		{
+			ID:        2,
+			SnippetID: mockSnippet.ID,
+			Title:     mockSnippet.Title,
+			Content:   "An old silent pond...\nA frog jumps in",
+			Author:    mockSnippet.Owner,
+			Created:   time.Now(),
+		},
+	}, nil
+}
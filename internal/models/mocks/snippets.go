@@ -2,36 +2,315 @@ package mocks
 
 import (
 	"asniki/snippetbox/internal/models"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
+// mockSnippet was created before the share slugs, so it can still be viewed by its numeric ID
 var mockSnippet = &models.Snippet{
-	ID:      1,
-	Title:   "An old silent pond",
-	Content: "An old silent pond...",
+	ID:         1,
+	Slug:       "AnOldSilent1",
+	LegacyLink: true,
+	Title:      "An old silent pond",
+	Content:    "An old silent pond...",
+	Format:     "plain",
+	Language:   "plaintext",
+	Visibility: "public",
+	Tags:       []string{"haiku", "poetry"},
+	Files: []models.SnippetFile{
+		{ID: 1, Name: "frog.go", Language: "go", Content: "package frog"},
+	},
+	Forks:   1,
 	Created: time.Now(),
 	Expires: time.Now(),
+	Owner: models.User{
+		ID:   1,
+		Name: "Bob",
+	},
 }
 
-// SnippetModel mocks models.SnippetModel
-type SnippetModel struct{}
+// mockForeignSnippet is owned by a user other than the one logged in by the tests and forked from mockSnippet
+var mockForeignSnippet = &models.Snippet{
+	ID:         3,
+	Slug:       "WintryForest",
+	Title:      "Over the wintry forest",
+	Content:    "Over the wintry forest...",
+	Format:     "plain",
+	Language:   "plaintext",
+	Visibility: "public",
+	ParentID:   1,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+	Owner: models.User{
+		ID:   2,
+		Name: "Alice",
+	},
+}
+
+// mockPrivateSnippet is a private snippet of the user logged in by the tests
+var mockPrivateSnippet = &models.Snippet{
+	ID:         4,
+	Slug:       "LightningFl4",
+	Title:      "A lightning flash",
+	Content:    "A lightning flash...",
+	Format:     "plain",
+	Language:   "plaintext",
+	Visibility: "private",
+	Created:    time.Now(),
+	Expires:    time.Now(),
+	Owner: models.User{
+		ID:   1,
+		Name: "Bob",
+	},
+}
+
+// mockBurnSnippet is a burn-after-reading snippet of the user logged in by the tests
+var mockBurnSnippet = &models.Snippet{
+	ID:               5,
+	Slug:             "ThisIsGone05",
+	Title:            "Deploy token",
+	Content:          "s3cr3t-t0k3n",
+	Format:           "plain",
+	Language:         "plaintext",
+	Visibility:       "public",
+	BurnAfterReading: true,
+	Created:          time.Now(),
+	Expires:          time.Now(),
+	Owner: models.User{
+		ID:   1,
+		Name: "Bob",
+	},
+}
+
+// mockProtectedSnippet is a password protected snippet of a user other than the one logged in by the tests,
+// created before the share slugs, its password is "open sesame"
+var mockProtectedSnippet = &models.Snippet{
+	ID:         6,
+	Slug:       "OpenSesame06",
+	LegacyLink: true,
+	Title:      "The cave",
+	Content:    "Forty thieves...",
+	Format:     "plain",
+	Language:   "plaintext",
+	Visibility: "public",
+	Protected:  true,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+	Owner: models.User{
+		ID:   2,
+		Name: "Alice",
+	},
+}
+
+// mockEncryptedSnippet is an unlisted encrypted snippet of the user logged in by the tests, created before the share slugs
+var mockEncryptedSnippet = &models.Snippet{
+	ID:         7,
+	Slug:       "Encrypted007",
+	LegacyLink: true,
+	Title:      "Launch codes",
+	Content:    "q83vASNFZ4mrze8BI0VniQ==",
+	Format:     "plain",
+	Language:   "plaintext",
+	Visibility: "unlisted",
+	Encrypted:  true,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+	Owner: models.User{
+		ID:   1,
+		Name: "Bob",
+	},
+}
+
+// SnippetModel mocks models.SnippetModel, it remembers whether mockBurnSnippet has been burned
+type SnippetModel struct {
+	mu     sync.Mutex
+	burned bool
+}
 
 // Insert mocks models.SnippetModel.Insert
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
-	return 2, nil
+func (m *SnippetModel) Insert(s *models.Snippet, password string) (int, error) {
+	s.ID = 2
+	s.Slug = "NewSnippet02"
+	s.Protected = password != ""
+	return s.ID, nil
 }
 
 // Get mocks models.SnippetModel.Get
 func (m *SnippetModel) Get(id int) (*models.Snippet, error) {
+	// return copies, so that the handlers changing the snippet cannot affect the other tests
 	switch id {
 	case 1:
-		return mockSnippet, nil
+		s := *mockSnippet
+		return &s, nil
+	case 3:
+		s := *mockForeignSnippet
+		return &s, nil
+	case 4:
+		s := *mockPrivateSnippet
+		return &s, nil
+	case 5:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.burned {
+			return nil, models.ErrNoRecord
+		}
+		s := *mockBurnSnippet
+		return &s, nil
+	case 6:
+		s := *mockProtectedSnippet
+		return &s, nil
+	case 7:
+		s := *mockEncryptedSnippet
+		return &s, nil
 	default:
 		return nil, models.ErrNoRecord
 	}
 }
 
+// GetBySlug mocks models.SnippetModel.GetBySlug
+func (m *SnippetModel) GetBySlug(slug string) (*models.Snippet, error) {
+	for _, s := range []*models.Snippet{mockSnippet, mockForeignSnippet, mockPrivateSnippet, mockBurnSnippet, mockProtectedSnippet,
+		mockEncryptedSnippet} {
+		if s.Slug == slug {
+			return m.Get(s.ID)
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+// Burn mocks models.SnippetModel.Burn
+func (m *SnippetModel) Burn(id int) (*models.Snippet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id != mockBurnSnippet.ID || m.burned {
+		return nil, models.ErrNoRecord
+	}
+
+	m.burned = true
+	s := *mockBurnSnippet
+	return &s, nil
+}
+
+// Unlock mocks models.SnippetModel.Unlock
+func (m *SnippetModel) Unlock(id int, password string) error {
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+
+	if id != mockProtectedSnippet.ID || password != "open sesame" {
+		return models.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// Update mocks models.SnippetModel.Update
+func (m *SnippetModel) Update(s *models.Snippet, editorID int) error {
+	return nil
+}
+
+// Delete mocks models.SnippetModel.Delete
+func (m *SnippetModel) Delete(id int) error {
+	return nil
+}
+
+// ExpireNow mocks models.SnippetModel.ExpireNow
+func (m *SnippetModel) ExpireNow(id int) error {
+	return nil
+}
+
+// DeleteExpired mocks models.SnippetModel.DeleteExpired
+func (m *SnippetModel) DeleteExpired(before time.Time, limit int) (int, error) {
+	return 0, nil
+}
+
+// ByTag mocks models.SnippetModel.ByTag
+func (m *SnippetModel) ByTag(tag string) ([]*models.Snippet, error) {
+	if slices.Contains(mockSnippet.Tags, tag) {
+		return []*models.Snippet{mockSnippet}, nil
+	}
+
+	return []*models.Snippet{}, nil
+}
+
 // Latest mocks models.SnippetModel.Latest
 func (m *SnippetModel) Latest() ([]*models.Snippet, error) {
 	return []*models.Snippet{mockSnippet}, nil
 }
+
+// ListByUser mocks models.SnippetModel.ListByUser
+func (m *SnippetModel) ListByUser(userID int, sort string, page int, pageSize int) ([]*models.Snippet, models.Metadata, error) {
+	if userID != 1 || page > 1 {
+		return []*models.Snippet{}, models.Metadata{}, nil
+	}
+
+	metadata := models.Metadata{
+		CurrentPage:  1,
+		PageSize:     pageSize,
+		LastPage:     1,
+		TotalRecords: 1,
+	}
+
+	return []*models.Snippet{mockSnippet}, metadata, nil
+}
+
+// Archive mocks models.SnippetModel.Archive
+func (m *SnippetModel) Archive(beforeID int, afterID int, page int, pageSize int) ([]*models.Snippet, models.Metadata, error) {
+	if beforeID > 0 && beforeID <= mockSnippet.ID {
+		return []*models.Snippet{}, models.Metadata{}, nil
+	}
+
+	metadata := models.Metadata{
+		CurrentPage:  1,
+		PageSize:     pageSize,
+		LastPage:     1,
+		TotalRecords: 1,
+		FirstID:      mockSnippet.ID,
+		LastID:       mockSnippet.ID,
+	}
+
+	return []*models.Snippet{mockSnippet}, metadata, nil
+}
+
+// Search mocks models.SnippetModel.Search
+func (m *SnippetModel) Search(query string) ([]*models.Snippet, error) {
+	if strings.Contains(strings.ToLower(mockSnippet.Content), strings.ToLower(query)) {
+		return []*models.Snippet{mockSnippet}, nil
+	}
+
+	return []*models.Snippet{}, nil
+}
+
+// Find mocks models.SnippetModel.Find
+func (m *SnippetModel) Find(filter models.SnippetFilter) ([]*models.Snippet, error) {
+	snippets := []*models.Snippet{}
+	for _, s := range []*models.Snippet{mockSnippet, mockForeignSnippet} {
+		if filter.UserID != 0 && s.Owner.ID != filter.UserID {
+			continue
+		}
+		if !filter.CreatedFrom.IsZero() && s.Created.Before(filter.CreatedFrom) {
+			continue
+		}
+		if !filter.CreatedTo.IsZero() && !s.Created.Before(filter.CreatedTo) {
+			continue
+		}
+		snippets = append(snippets, s)
+	}
+
+	return snippets, nil
+}
+
+// DeleteWhere mocks models.SnippetModel.DeleteWhere
+func (m *SnippetModel) DeleteWhere(filter models.SnippetFilter) (int, error) {
+	snippets, err := m.Find(filter)
+	return len(snippets), err
+}
+
+// Stats mocks models.SnippetModel.Stats
+func (m *SnippetModel) Stats() (models.SnippetStats, error) {
+	return models.SnippetStats{Total: 7, Public: 2, Unlisted: 4, Private: 1, BurnAfterReading: 1, Protected: 1, Encrypted: 1}, nil
+}
@@ -0,0 +1,70 @@
+package mocks
+
+import (
+	"asniki/snippetbox/internal/models"
+	"time"
+)
+
+var mockToken = &models.Token{
+	ID:      1,
+	Name:    "Deploy script",
+	Scope:   "write",
+	UserID:  1,
+	Created: time.Now(),
+	Expiry:  time.Now().Add(30 * 24 * time.Hour),
+}
+
+var mockReadOnlyToken = &models.Token{
+	ID:      2,
+	Name:    "Dashboard",
+	Scope:   "read",
+	UserID:  1,
+	Created: time.Now(),
+	Expiry:  time.Now().Add(7 * 24 * time.Hour),
+}
+
+// TokenModel mocks models.TokenModel
+type TokenModel struct{}
+
+// New mocks models.TokenModel.New
+func (m *TokenModel) New(userID int, name, scope string, ttl time.Duration) (*models.Token, error) {
+	return &models.Token{
+		ID:        3,
+		Name:      name,
+		Scope:     scope,
+		Plaintext: "NewToken0003",
+		UserID:    userID,
+		Created:   time.Now().UTC(),
+		Expiry:    time.Now().UTC().Add(ttl),
+	}, nil
+}
+
+// GetByPlaintext mocks models.TokenModel.GetByPlaintext
+func (m *TokenModel) GetByPlaintext(plaintext string) (*models.Token, error) {
+	switch plaintext {
+	case "ValidBobToken":
+		t := *mockToken
+		return &t, nil
+	case "ReadOnlyBobToken":
+		t := *mockReadOnlyToken
+		return &t, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+// ListByUser mocks models.TokenModel.ListByUser
+func (m *TokenModel) ListByUser(userID int) ([]*models.Token, error) {
+	if userID == 1 {
+		return []*models.Token{mockToken, mockReadOnlyToken}, nil
+	}
+	return []*models.Token{}, nil
+}
+
+// Delete mocks models.TokenModel.Delete
+func (m *TokenModel) Delete(id, userID int) error {
+	if userID == 1 && (id == mockToken.ID || id == mockReadOnlyToken.ID) {
+		return nil
+	}
+	return models.ErrNoRecord
+}
@@ -23,6 +23,9 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 	if email == "bob@example.com" && password == "validPa$$word" {
 		return 1, nil
 	}
+	if email == "carol@example.com" && password == "validPa$$word" {
+		return 0, models.ErrAccountDisabled
+	}
 
 	return 0, models.ErrInvalidCredentials
 }
@@ -47,6 +50,26 @@ func (m *UserModel) Get(id int) (*models.User, error) {
 			Email:   "bob@example.com",
 			Created: time.Now(),
 		}, nil
+	case 3:
+		return &models.User{
+			ID:       3,
+			Name:     "Carol",
+			Email:    "carol@example.com",
+			Disabled: true,
+			Created:  time.Now(),
+		}, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+// GetByEmail mocks models.UserModel.GetByEmail
+func (m *UserModel) GetByEmail(email string) (*models.User, error) {
+	switch email {
+	case "bob@example.com":
+		return m.Get(1)
+	case "carol@example.com":
+		return m.Get(3)
 	default:
 		return nil, models.ErrNoRecord
 	}
@@ -62,3 +85,24 @@ func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string)
 	}
 	return models.ErrNoRecord
 }
+
+// PasswordReset mocks models.UserModel.PasswordReset
+func (m *UserModel) PasswordReset(id int, newPassword string) error {
+	if id == 1 || id == 3 {
+		return nil
+	}
+	return models.ErrNoRecord
+}
+
+// SetDisabled mocks models.UserModel.SetDisabled
+func (m *UserModel) SetDisabled(id int, disabled bool) error {
+	if id == 1 || id == 3 {
+		return nil
+	}
+	return models.ErrNoRecord
+}
+
+// Stats mocks models.UserModel.Stats
+func (m *UserModel) Stats() (models.UserStats, error) {
+	return models.UserStats{Total: 3, Disabled: 1}, nil
+}
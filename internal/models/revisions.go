@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Revision holds a saved version of a snippet
+type Revision struct {
+	ID        int
+	SnippetID int
+	Title     string
+	Content   string
+	Author    User
+	Created   time.Time
+}
+
+// RevisionModelInterface describes the methods for the RevisionModel
+type RevisionModelInterface interface {
+	List(snippetID int) ([]*Revision, error)
+}
+
+// RevisionModel wraps a database connection pool and provides methods to access the snippet revisions.
+// The revisions are written by the SnippetModel whenever a snippet is created or updated
+type RevisionModel struct {
+	DB *sql.DB
+}
+
+// insertRevision saves the current version of a snippet as a new revision within the transaction
+func insertRevision(tx *sql.Tx, snippetID int, userID int, title string, content string) error {
+	stmt := `INSERT INTO snippet_revisions (snippet_id, user_id, title, content, created)
+    VALUES(?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err := tx.Exec(stmt, snippetID, userID, title, content)
+	return err
+}
+
+// List returns all the revisions of the snippet from the oldest to the newest
+func (m *RevisionModel) List(snippetID int) ([]*Revision, error) {
+	stmt := `SELECT r.id, r.snippet_id, r.title, r.content, r.created, u.id, u.name
+    FROM snippet_revisions r INNER JOIN users u ON u.id = r.user_id
+    WHERE r.snippet_id = ? ORDER BY r.id ASC`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+	for rows.Next() {
+		r := &Revision{}
+		err = rows.Scan(&r.ID, &r.SnippetID, &r.Title, &r.Content, &r.Created, &r.Author.ID, &r.Author.Name)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
@@ -1,25 +1,192 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// Snippet holds the data for an individual snippet
+// Snippet holds the data for an individual snippet.
+// Slug is the random URL-safe identifier used for sharing the snippet.
+// Visibility is one of "public", "unlisted" (hidden from the listings and search) or "private" (owner only).
+// A BurnAfterReading snippet is deleted as soon as it is read by anyone other than its owner.
+// A Protected snippet has a password, which anyone other than its owner must enter to read it.
+// The Content of an Encrypted snippet is ciphertext, which only the browsers holding the key can decrypt.
+// A zero Expires time means the snippet never expires. ParentID is the ID of the snippet this one
+// was forked from or 0, Forks is the number of the snippets forked from this one.
+// Files are the named files bundled with the snippet in addition to its own content.
+// LegacyLink is true for the snippets created before the share slugs, which can still be looked up
+// by their numeric ID on the web
 type Snippet struct {
-	ID      int
-	Title   string
-	Content string
-	Created time.Time
-	Expires time.Time
+	ID               int           `json:"id"`
+	Slug             string        `json:"slug"`
+	LegacyLink       bool          `json:"-"`
+	Title            string        `json:"title"`
+	Content          string        `json:"content"`
+	Format           string        `json:"format"`
+	Language         string        `json:"language"`
+	Visibility       string        `json:"visibility"`
+	BurnAfterReading bool          `json:"burn_after_reading"`
+	Protected        bool          `json:"protected"`
+	Encrypted        bool          `json:"encrypted"`
+	Tags             []string      `json:"tags"`
+	Files            []SnippetFile `json:"files"`
+	ParentID         int           `json:"parent_id"`
+	Forks            int           `json:"forks"`
+	Created          time.Time     `json:"created"`
+	Expires          time.Time     `json:"expires"`
+	Owner            User          `json:"owner"`
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the zero Expires time of a snippet
+// which never expires as null
+func (s Snippet) MarshalJSON() ([]byte, error) {
+	type snippet Snippet
+
+	var expires *time.Time
+	if !s.Expires.IsZero() {
+		expires = &s.Expires
+	}
+
+	return json.Marshal(struct {
+		snippet
+		Expires *time.Time `json:"expires"`
+	}{snippet(s), expires})
+}
+
+// Expired returns true if the snippet is past its expiry date
+func (s *Snippet) Expired() bool {
+	return !s.Expires.IsZero() && !s.Expires.After(time.Now())
+}
+
+// nullableTime scans a nullable DATETIME column into a time.Time, which is left zero for NULL
+type nullableTime struct {
+	t *time.Time
+}
+
+// Scan implements the sql.Scanner interface
+func (n nullableTime) Scan(value any) error {
+	var nt sql.NullTime
+	err := nt.Scan(value)
+	if err != nil {
+		return err
+	}
+
+	*n.t = nt.Time
+	return nil
+}
+
+// VisibleTo returns true if the user with the given ID may view the snippet,
+// pass 0 for an anonymous user
+func (s *Snippet) VisibleTo(userID int) bool {
+	return s.Visibility != "private" || s.Owner.ID == userID
+}
+
+// Listed returns true if the snippet appears in the public listings: it is public, and neither burned
+// after reading nor protected by a password
+func (s *Snippet) Listed() bool {
+	return s.Visibility == "public" && !s.BurnAfterReading && !s.Protected
+}
+
+// VisibleByIDTo returns true if the user with the given ID may look the snippet up by its numeric ID.
+// As the IDs can be enumerated, the snippets of other users missing from the public listings can only be
+// reached through their share slug, unless they are public snippets created before the share slugs
+func (s *Snippet) VisibleByIDTo(userID int) bool {
+	if s.Owner.ID == userID {
+		return true
+	}
+	return s.Listed() || (s.Visibility == "public" && s.LegacyLink)
+}
+
+// SnippetSortValues lists the permitted values for ordering snippet listings,
+// a leading "-" means descending order
+var SnippetSortValues = []string{"title", "-title", "created", "-created", "expires", "-expires"}
+
+// snippetSortClauses maps the permitted sort values to the ORDER BY clauses
+var snippetSortClauses = map[string]string{
+	"title":    "title ASC, id ASC",
+	"-title":   "title DESC, id DESC",
+	"created":  "created ASC, id ASC",
+	"-created": "created DESC, id DESC",
+	"expires":  "expires IS NULL ASC, expires ASC, id ASC",
+	"-expires": "expires IS NULL DESC, expires DESC, id DESC",
+}
+
+// SnippetFilter selects the snippets for the maintenance queries. A non-zero UserID selects the snippets
+// of that user, non-zero CreatedFrom and CreatedTo select the snippets created at or after CreatedFrom
+// and before CreatedTo. The zero filter selects every snippet
+type SnippetFilter struct {
+	UserID      int
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// IsZero returns true if the filter selects every snippet
+func (f SnippetFilter) IsZero() bool {
+	return f.UserID == 0 && f.CreatedFrom.IsZero() && f.CreatedTo.IsZero()
+}
+
+// where returns the conditions of the filter on the snippets table aliased as s, and their arguments
+func (f SnippetFilter) where() (string, []any) {
+	conditions := []string{"true"}
+	args := []any{}
+
+	if f.UserID != 0 {
+		conditions = append(conditions, "s.user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if !f.CreatedFrom.IsZero() {
+		conditions = append(conditions, "s.created >= ?")
+		args = append(args, f.CreatedFrom.UTC())
+	}
+	if !f.CreatedTo.IsZero() {
+		conditions = append(conditions, "s.created < ?")
+		args = append(args, f.CreatedTo.UTC())
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// SnippetStats holds the number of the snippets, in total and by kind
+type SnippetStats struct {
+	Total            int
+	Expired          int
+	Public           int
+	Unlisted         int
+	Private          int
+	BurnAfterReading int
+	Protected        int
+	Encrypted        int
 }
 
 // SnippetModelInterface describes the methods for the SnippetModel
 type SnippetModelInterface interface {
-	Insert(title string, content string, expires int) (int, error)
+	Insert(s *Snippet, password string) (int, error)
 	Get(id int) (*Snippet, error)
+	GetBySlug(slug string) (*Snippet, error)
+	Burn(id int) (*Snippet, error)
+	Unlock(id int, password string) error
+	Update(s *Snippet, editorID int) error
+	Delete(id int) error
+	ExpireNow(id int) error
+	DeleteExpired(before time.Time, limit int) (int, error)
+	ListByUser(userID int, sort string, page int, pageSize int) ([]*Snippet, Metadata, error)
+	Archive(beforeID int, afterID int, page int, pageSize int) ([]*Snippet, Metadata, error)
+	Search(query string) ([]*Snippet, error)
+	ByTag(tag string) ([]*Snippet, error)
 	Latest() ([]*Snippet, error)
+	Find(filter SnippetFilter) ([]*Snippet, error)
+	DeleteWhere(filter SnippetFilter) (int, error)
+	Stats() (SnippetStats, error)
 }
 
 // SnippetModel wraps a database connection pool and provides methods to access and manipulate the snippets
@@ -27,32 +194,112 @@ type SnippetModel struct {
 	DB *sql.DB
 }
 
-// Insert inserts a new snippet into the database
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
-	stmt := `INSERT INTO snippets (title, content, created, expires)
-    VALUES(?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY))`
+// newSlug generates a random URL-safe slug of 12 characters
+func newSlug() (string, error) {
+	b := make([]byte, 9)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	result, err := m.DB.Exec(stmt, title, content, expires)
+// Insert inserts a new snippet owned by s.Owner into the database, expiring at s.Expires or never if it is zero,
+// and saves it as the first revision of the snippet. A non-empty password protects the snippet.
+// The generated ID and slug are set on s
+func (m *SnippetModel) Insert(s *Snippet, password string) (int, error) {
+	var hashedPassword sql.NullString
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+		if err != nil {
+			return 0, err
+		}
+		hashedPassword = sql.NullString{String: string(hash), Valid: true}
+	}
+	s.Protected = hashedPassword.Valid
+
+	expires := sql.NullTime{Time: s.Expires.UTC(), Valid: !s.Expires.IsZero()}
+	parentID := sql.NullInt64{Int64: int64(s.ParentID), Valid: s.ParentID != 0}
+
+	tx, err := m.DB.Begin()
 	if err != nil {
 		return 0, err
 	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippets (slug, user_id, title, content, format, language, visibility, burn_after_reading,
+    hashed_password, encrypted, parent_id, created, expires)
+    VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP(), ?)`
+
+	var result sql.Result
+	// a slug collision is very unlikely, but retry with a fresh slug rather than fail
+	for attempt := 1; ; attempt++ {
+		s.Slug, err = newSlug()
+		if err != nil {
+			return 0, err
+		}
+
+		result, err = tx.Exec(stmt, s.Slug, s.Owner.ID, s.Title, s.Content, s.Format, s.Language, s.Visibility, s.BurnAfterReading,
+			hashedPassword, s.Encrypted, parentID, expires)
+		if err == nil {
+			break
+		}
+
+		var mySQLError *mysql.MySQLError
+		if attempt == 3 || !errors.As(err, &mySQLError) ||
+			mySQLError.Number != 1062 || !strings.Contains(mySQLError.Message, "snippets_uc_slug") {
+			return 0, err
+		}
+	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
+	s.ID = int(id)
+
+	err = insertRevision(tx, s.ID, s.Owner.ID, s.Title, s.Content)
+	if err != nil {
+		return 0, err
+	}
+
+	err = setTags(tx, s.ID, s.Tags)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertFiles(tx, s.ID, s.Files)
+	if err != nil {
+		return 0, err
+	}
 
-	return int(id), nil
+	return s.ID, tx.Commit()
 }
 
-// Get returns the snippet by id
+// Get returns the snippet by id together with its owner, tags, files, parent ID and number of forks
 func (m *SnippetModel) Get(id int) (*Snippet, error) {
-	stmt := `SELECT id, title, content, created, expires FROM snippets
-    WHERE expires > UTC_TIMESTAMP() AND id = ?`
+	return m.getBy("s.id", id)
+}
+
+// GetBySlug returns the snippet by its share slug together with its owner
+func (m *SnippetModel) GetBySlug(slug string) (*Snippet, error) {
+	return m.getBy("s.slug", slug)
+}
+
+// getBy returns the live snippet with the given value of a uniquely indexed column
+func (m *SnippetModel) getBy(column string, value any) (*Snippet, error) {
+	stmt := `SELECT s.id, s.slug, s.legacy_link, s.title, s.content, s.format, s.language, s.visibility, s.burn_after_reading,
+    s.hashed_password IS NOT NULL, s.encrypted, s.created, s.expires, u.id, u.name, ` + tagsColumn + `,
+    COALESCE(s.parent_id, 0), (SELECT COUNT(*) FROM snippets f WHERE f.parent_id = s.id)
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND ` + column + ` = ?`
 
 	s := &Snippet{}
-	row := m.DB.QueryRow(stmt, id)
-	err := row.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+	row := m.DB.QueryRow(stmt, value)
+	err := row.Scan(&s.ID, &s.Slug, &s.LegacyLink, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.BurnAfterReading,
+		&s.Protected, &s.Encrypted, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name, tagList{&s.Tags},
+		&s.ParentID, &s.Forks)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -61,13 +308,221 @@ func (m *SnippetModel) Get(id int) (*Snippet, error) {
 		}
 	}
 
+	s.Files, err = listFiles(m.DB, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return s, nil
 }
 
-// Latest returns the 10 most recently created snippets
+// Burn returns the live burn-after-reading snippet by id and deletes it in the same transaction.
+// The snippet row stays locked until the deletion is committed, so concurrent readers cannot both
+// get it: all but the first one receive ErrNoRecord
+func (m *SnippetModel) Burn(id int) (*Snippet, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt := `SELECT s.id, s.slug, s.title, s.content, s.format, s.language, s.visibility, s.burn_after_reading,
+    s.hashed_password IS NOT NULL, s.encrypted, s.created, s.expires, u.id, u.name, ` + tagsColumn + `
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.burn_after_reading AND s.id = ?
+    FOR UPDATE OF s`
+
+	s := &Snippet{}
+	row := tx.QueryRow(stmt, id)
+	err = row.Scan(&s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.BurnAfterReading,
+		&s.Protected, &s.Encrypted, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name, tagList{&s.Tags})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	s.Files, err = listFiles(tx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM snippets WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, tx.Commit()
+}
+
+// Unlock verifies the password of a protected snippet. It returns ErrInvalidCredentials
+// if the password is incorrect or the snippet is not protected
+func (m *SnippetModel) Unlock(id int, password string) error {
+	var hashedPassword sql.NullString
+
+	stmt := "SELECT hashed_password FROM snippets WHERE (expires IS NULL OR expires > UTC_TIMESTAMP()) AND id = ?"
+
+	err := m.DB.QueryRow(stmt, id).Scan(&hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		} else {
+			return err
+		}
+	}
+
+	if !hashedPassword.Valid {
+		return ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword.String), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update saves the title, content, format, language, visibility and tags of an existing snippet
+// and records the new version as a revision authored by the editor
+func (m *SnippetModel) Update(s *Snippet, editorID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `UPDATE snippets SET title = ?, content = ?, format = ?, language = ?, visibility = ?
+    WHERE id = ?`
+
+	_, err = tx.Exec(stmt, s.Title, s.Content, s.Format, s.Language, s.Visibility, s.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertRevision(tx, s.ID, editorID, s.Title, s.Content)
+	if err != nil {
+		return err
+	}
+
+	err = setTags(tx, s.ID, s.Tags)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the snippet from the database, its revisions are removed by the foreign key cascade
+func (m *SnippetModel) Delete(id int) error {
+	stmt := "DELETE FROM snippets WHERE id = ?"
+
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+// ExpireNow sets the snippet expiry date to the current time, so it is no longer served
+func (m *SnippetModel) ExpireNow(id int) error {
+	stmt := "UPDATE snippets SET expires = UTC_TIMESTAMP() WHERE id = ?"
+
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+// DeleteExpired removes up to limit snippets which expired before the given time, together with their revisions,
+// and returns the number of snippets removed
+func (m *SnippetModel) DeleteExpired(before time.Time, limit int) (int, error) {
+	stmt := "DELETE FROM snippets WHERE expires IS NOT NULL AND expires < ? ORDER BY expires LIMIT ?"
+
+	result, err := m.DB.Exec(stmt, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// Find returns every snippet matching the filter together with its owner, the oldest first, whatever its
+// visibility or expiry. The content is left out, the files and the tags are not loaded
+func (m *SnippetModel) Find(filter SnippetFilter) ([]*Snippet, error) {
+	where, args := filter.where()
+	stmt := `SELECT s.id, s.slug, s.title, s.format, s.language, s.visibility, s.burn_after_reading,
+    s.hashed_password IS NOT NULL, s.encrypted, s.created, s.expires, u.id, u.name, u.email
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE ` + where + ` ORDER BY s.created, s.id`
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s := &Snippet{}
+		err = rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Format, &s.Language, &s.Visibility, &s.BurnAfterReading,
+			&s.Protected, &s.Encrypted, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name, &s.Owner.Email)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// DeleteWhere removes the snippets matching the filter, together with their revisions and files,
+// and returns the number of snippets removed
+func (m *SnippetModel) DeleteWhere(filter SnippetFilter) (int, error) {
+	where, args := filter.where()
+	stmt := "DELETE s FROM snippets s WHERE " + where
+
+	result, err := m.DB.Exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// Stats returns the number of the snippets, the expired ones which the janitor has not purged yet included
+func (m *SnippetModel) Stats() (SnippetStats, error) {
+	stmt := `SELECT COUNT(*),
+    COALESCE(SUM(expires IS NOT NULL AND expires <= UTC_TIMESTAMP()), 0),
+    COALESCE(SUM(visibility = 'public'), 0),
+    COALESCE(SUM(visibility = 'unlisted'), 0),
+    COALESCE(SUM(visibility = 'private'), 0),
+    COALESCE(SUM(burn_after_reading), 0),
+    COALESCE(SUM(hashed_password IS NOT NULL), 0),
+    COALESCE(SUM(encrypted), 0)
+    FROM snippets`
+
+	var stats SnippetStats
+	err := m.DB.QueryRow(stmt).Scan(&stats.Total, &stats.Expired, &stats.Public, &stats.Unlisted, &stats.Private,
+		&stats.BurnAfterReading, &stats.Protected, &stats.Encrypted)
+	return stats, err
+}
+
+// Latest returns the 10 most recently created public snippets together with their owners.
+// Burn-after-reading and protected snippets are never listed, as that would let anyone burn them
+// or read their content around the password
 func (m *SnippetModel) Latest() ([]*Snippet, error) {
-	stmt := `SELECT id, title, content, created, expires FROM snippets
-    WHERE expires > UTC_TIMESTAMP() ORDER BY id DESC LIMIT 10`
+	stmt := `SELECT s.id, s.slug, s.title, s.content, s.format, s.language, s.visibility, s.created, s.expires, u.id, u.name,
+    ` + tagsColumn + `
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading AND s.hashed_password IS NULL ORDER BY s.id DESC LIMIT 10`
 
 	rows, err := m.DB.Query(stmt)
 	if err != nil {
@@ -78,7 +533,174 @@ func (m *SnippetModel) Latest() ([]*Snippet, error) {
 	snippets := []*Snippet{}
 	for rows.Next() {
 		s := &Snippet{}
-		err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+		err = rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name,
+			tagList{&s.Tags})
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// ListByUser returns a page of the snippets owned by the user, including the expired ones,
+// ordered by one of the SnippetSortValues
+func (m *SnippetModel) ListByUser(userID int, sort string, page int, pageSize int) ([]*Snippet, Metadata, error) {
+	orderBy, ok := snippetSortClauses[sort]
+	if !ok {
+		return nil, Metadata{}, fmt.Errorf("models: invalid sort value %q", sort)
+	}
+
+	stmt := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, slug, title, content, format, language, visibility, burn_after_reading,
+    hashed_password IS NOT NULL, encrypted, created, expires
+    FROM snippets
+    WHERE user_id = ? ORDER BY %s LIMIT ? OFFSET ?`, orderBy)
+
+	rows, err := m.DB.Query(stmt, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s := &Snippet{Owner: User{ID: userID}}
+		err = rows.Scan(&totalRecords, &s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility,
+			&s.BurnAfterReading, &s.Protected, &s.Encrypted, &s.Created, nullableTime{&s.Expires})
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return snippets, calculateMetadata(totalRecords, page, pageSize), nil
+}
+
+// Archive returns a page of live public snippets ordered from the newest to the oldest using keyset pagination
+// on the id column. A non-zero beforeID selects the page of snippets older than it, a non-zero afterID
+// selects the page of snippets newer than it, otherwise the newest page is returned.
+// The page number is only used to fill in the metadata
+func (m *SnippetModel) Archive(beforeID int, afterID int, page int, pageSize int) ([]*Snippet, Metadata, error) {
+	var totalRecords int
+	stmt := "SELECT COUNT(*) FROM snippets WHERE (expires IS NULL OR expires > UTC_TIMESTAMP()) AND visibility = 'public' AND NOT burn_after_reading AND hashed_password IS NULL"
+
+	err := m.DB.QueryRow(stmt).Scan(&totalRecords)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	stmt = `SELECT s.id, s.slug, s.title, s.content, s.format, s.language, s.visibility, s.created, s.expires, u.id, u.name
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading AND s.hashed_password IS NULL %s LIMIT ?`
+
+	var args []any
+	switch {
+	case beforeID > 0:
+		stmt = fmt.Sprintf(stmt, "AND s.id < ? ORDER BY s.id DESC")
+		args = append(args, beforeID)
+	case afterID > 0:
+		stmt = fmt.Sprintf(stmt, "AND s.id > ? ORDER BY s.id ASC")
+		args = append(args, afterID)
+	default:
+		stmt = fmt.Sprintf(stmt, "ORDER BY s.id DESC")
+	}
+	args = append(args, pageSize)
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s := &Snippet{}
+		err = rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	// the newer page is read in ascending order so that the LIMIT keeps the snippets closest to afterID
+	if afterID > 0 {
+		slices.Reverse(snippets)
+	}
+
+	metadata := calculateMetadata(totalRecords, page, pageSize)
+	if len(snippets) > 0 {
+		metadata.FirstID = snippets[0].ID
+		metadata.LastID = snippets[len(snippets)-1].ID
+	}
+
+	return snippets, metadata, nil
+}
+
+// Search returns up to 50 live public snippets matching the query in their title or content,
+// ordered by relevance. Encrypted snippets are left out as their content cannot be searched
+func (m *SnippetModel) Search(query string) ([]*Snippet, error) {
+	stmt := `SELECT s.id, s.slug, s.title, s.content, s.format, s.language, s.visibility, s.created, s.expires, u.id, u.name
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading AND s.hashed_password IS NULL AND NOT s.encrypted AND MATCH(s.title, s.content) AGAINST(? IN NATURAL LANGUAGE MODE)
+    ORDER BY MATCH(s.title, s.content) AGAINST(? IN NATURAL LANGUAGE MODE) DESC, s.id DESC LIMIT 50`
+
+	rows, err := m.DB.Query(stmt, query, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s := &Snippet{}
+		err = rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// ByTag returns up to 50 live public snippets with the tag together with their owners, the newest first
+func (m *SnippetModel) ByTag(tag string) ([]*Snippet, error) {
+	stmt := `SELECT s.id, s.slug, s.title, s.content, s.format, s.language, s.visibility, s.created, s.expires, u.id, u.name,
+    ` + tagsColumn + `
+    FROM snippets s INNER JOIN users u ON u.id = s.user_id
+    INNER JOIN snippet_tags st ON st.snippet_id = s.id INNER JOIN tags t ON t.id = st.tag_id
+    WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading AND s.hashed_password IS NULL
+    AND t.name = ? ORDER BY s.id DESC LIMIT 50`
+
+	rows, err := m.DB.Query(stmt, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s := &Snippet{}
+		err = rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Content, &s.Format, &s.Language, &s.Visibility, &s.Created, nullableTime{&s.Expires}, &s.Owner.ID, &s.Owner.Name,
+			tagList{&s.Tags})
 		if err != nil {
 			return nil, err
 		}
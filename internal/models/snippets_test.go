@@ -0,0 +1,90 @@
+package models
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// insertTestSnippet inserts a snippet owned by the first user of the test database and returns its ID
+func insertTestSnippet(t *testing.T, m *SnippetModel, burnAfterReading bool) int {
+	s := &Snippet{
+		Title:            "Deploy token",
+		Content:          "s3cr3t-t0k3n",
+		Format:           "plain",
+		Language:         "plaintext",
+		Visibility:       "unlisted",
+		BurnAfterReading: burnAfterReading,
+		Owner:            User{ID: 1},
+	}
+
+	id, err := m.Insert(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestSnippetModelBurn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	t.Run("Burn once", func(t *testing.T) {
+		db := newTestDB(t)
+		m := &SnippetModel{db}
+		id := insertTestSnippet(t, m, true)
+
+		s, err := m.Burn(id)
+		assert.NilError(t, err)
+		assert.Equal(t, s.ID, id)
+		assert.Equal(t, s.Content, "s3cr3t-t0k3n")
+
+		_, err = m.Burn(id)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+		_, err = m.Get(id)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+	})
+
+	t.Run("Concurrent readers", func(t *testing.T) {
+		db := newTestDB(t)
+		m := &SnippetModel{db}
+		id := insertTestSnippet(t, m, true)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 10)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = m.Burn(id)
+			}()
+		}
+		wg.Wait()
+
+		read := 0
+		for _, err := range errs {
+			if err == nil {
+				read++
+			} else if !errors.Is(err, ErrNoRecord) {
+				t.Fatal(err)
+			}
+		}
+		assert.Equal(t, read, 1)
+	})
+
+	t.Run("Not burn after reading", func(t *testing.T) {
+		db := newTestDB(t)
+		m := &SnippetModel{db}
+		id := insertTestSnippet(t, m, false)
+
+		_, err := m.Burn(id)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+		_, err = m.Get(id)
+		assert.NilError(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// tagsColumn selects the tags of the snippet aliased s as a comma separated list ordered by name
+const tagsColumn = `(SELECT GROUP_CONCAT(t.name ORDER BY t.name SEPARATOR ',')
+    FROM snippet_tags st INNER JOIN tags t ON t.id = st.tag_id WHERE st.snippet_id = s.id)`
+
+// tagList scans the comma separated list selected by tagsColumn into a slice of tags, which is left nil for NULL
+type tagList struct {
+	tags *[]string
+}
+
+// Scan implements the sql.Scanner interface
+func (l tagList) Scan(value any) error {
+	var ns sql.NullString
+	err := ns.Scan(value)
+	if err != nil {
+		return err
+	}
+
+	*l.tags = nil
+	if ns.String != "" {
+		*l.tags = strings.Split(ns.String, ",")
+	}
+	return nil
+}
+
+// setTags replaces the tags of the snippet within the transaction, creating the tags which do not exist yet
+func setTags(tx *sql.Tx, snippetID int, tags []string) error {
+	_, err := tx.Exec("DELETE FROM snippet_tags WHERE snippet_id = ?", snippetID)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err = tx.Exec("INSERT INTO tags (name) VALUES (?) ON DUPLICATE KEY UPDATE id = id", tag)
+		if err != nil {
+			return err
+		}
+
+		stmt := "INSERT INTO snippet_tags (snippet_id, tag_id) SELECT ?, id FROM tags WHERE name = ?"
+
+		_, err = tx.Exec(stmt, snippetID, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
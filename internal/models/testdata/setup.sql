@@ -1,26 +1,96 @@
+CREATE TABLE users (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    hashed_password CHAR(60) NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created DATETIME NOT NULL
+);
+
+ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+
 CREATE TABLE snippets (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    slug CHAR(12) NOT NULL,
+    legacy_link BOOLEAN NOT NULL DEFAULT FALSE,
+    user_id INTEGER NOT NULL,
     title VARCHAR(100) NOT NULL,
     content TEXT NOT NULL,
+    format VARCHAR(16) NOT NULL DEFAULT 'plain',
+    language VARCHAR(32) NOT NULL DEFAULT 'plaintext',
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public',
+    burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE,
+    hashed_password CHAR(60) NULL,
+    encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    parent_id INTEGER NULL,
     created DATETIME NOT NULL,
-    expires DATETIME NOT NULL
+    expires DATETIME NULL,
+    CONSTRAINT snippets_uc_slug UNIQUE (slug),
+    CONSTRAINT fk_snippets_user FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT fk_snippets_parent FOREIGN KEY (parent_id) REFERENCES snippets(id) ON DELETE SET NULL
 );
 
 CREATE INDEX idx_snippets_created ON snippets(created);
 
-CREATE TABLE users (
+CREATE FULLTEXT INDEX idx_snippets_fulltext ON snippets(title, content);
+
+CREATE TABLE snippet_revisions (
     id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
-    name VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL,
-    hashed_password CHAR(60) NOT NULL,
-    created DATETIME NOT NULL
+    snippet_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT fk_snippet_revisions_snippet FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_snippet_revisions_user FOREIGN KEY (user_id) REFERENCES users(id)
 );
 
-ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+CREATE TABLE tags (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(32) NOT NULL,
+    CONSTRAINT tags_uc_name UNIQUE (name)
+);
+
+CREATE TABLE snippet_tags (
+    snippet_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (snippet_id, tag_id),
+    CONSTRAINT fk_snippet_tags_snippet FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_snippet_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE TABLE snippet_files (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    snippet_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    language VARCHAR(32) NOT NULL DEFAULT 'plaintext',
+    content TEXT NOT NULL,
+    CONSTRAINT snippet_files_uc_name UNIQUE (snippet_id, name),
+    CONSTRAINT fk_snippet_files_snippet FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    hash BINARY(32) NOT NULL,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    scope VARCHAR(16) NOT NULL,
+    created DATETIME NOT NULL,
+    expiry DATETIME NOT NULL,
+    CONSTRAINT tokens_uc_hash UNIQUE (hash),
+    CONSTRAINT fk_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
 
 INSERT INTO users (name, email, hashed_password, created) VALUES (
     'Alice Jones',
     'alice@example.com',
     '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
     '2025-01-01 10:01:02'
-);
\ No newline at end of file
+);
+INSERT INTO users (name, email, hashed_password, disabled, created) VALUES (
+    'Carol Smith',
+    'carol@example.com',
+    '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
+    TRUE,
+    '2025-01-02 10:01:02'
+);
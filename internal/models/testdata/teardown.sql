@@ -1,3 +1,13 @@
-DROP TABLE users;
+DROP TABLE tokens;
 
-DROP TABLE snippets;
\ No newline at end of file
+DROP TABLE snippet_files;
+
+DROP TABLE snippet_tags;
+
+DROP TABLE tags;
+
+DROP TABLE snippet_revisions;
+
+DROP TABLE snippets;
+
+DROP TABLE users;
\ No newline at end of file
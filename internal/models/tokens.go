@@ -0,0 +1,171 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"time"
+)
+
+// TokenScopes lists the permitted API token scopes: "read" gives access to the private snippets
+// of the user, "write" also allows creating, updating and deleting the snippets
+var TokenScopes = []string{"read", "write"}
+
+// Token holds a personal API token of a user. Plaintext is only known when the token is created,
+// the database keeps its SHA-256 Hash
+type Token struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	Plaintext string    `json:"token,omitempty"`
+	Hash      []byte    `json:"-"`
+	UserID    int       `json:"-"`
+	Created   time.Time `json:"created"`
+	Expiry    time.Time `json:"expiry"`
+}
+
+// Expired returns true if the token is past its expiry date
+func (t *Token) Expired() bool {
+	return !t.Expiry.After(time.Now())
+}
+
+// Allows returns true if the token grants the scope, the "write" scope includes "read"
+func (t *Token) Allows(scope string) bool {
+	return t.Scope == scope || (t.Scope == "write" && slices.Contains(TokenScopes, scope))
+}
+
+// TokenModelInterface describes the methods for the TokenModel
+type TokenModelInterface interface {
+	New(userID int, name, scope string, ttl time.Duration) (*Token, error)
+	GetByPlaintext(plaintext string) (*Token, error)
+	ListByUser(userID int) ([]*Token, error)
+	Delete(id, userID int) error
+}
+
+// TokenModel wraps a database connection pool and provides methods to access and manipulate the API tokens
+type TokenModel struct {
+	DB *sql.DB
+}
+
+// hashToken returns the SHA-256 hash of the plaintext token
+func hashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+// generateToken returns a new random token for the user, valid for the ttl
+func generateToken(userID int, name, scope string, ttl time.Duration) (*Token, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	return &Token{
+		Name:      name,
+		Scope:     scope,
+		Plaintext: plaintext,
+		Hash:      hashToken(plaintext),
+		UserID:    userID,
+		Created:   now,
+		Expiry:    now.Add(ttl),
+	}, nil
+}
+
+// New generates a new token for the user and saves its hash into the database
+func (m *TokenModel) New(userID int, name, scope string, ttl time.Duration) (*Token, error) {
+	token, err := generateToken(userID, name, scope, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := `INSERT INTO tokens (hash, user_id, name, scope, created, expiry)
+    VALUES(?, ?, ?, ?, ?, ?)`
+
+	result, err := m.DB.Exec(stmt, token.Hash, token.UserID, token.Name, token.Scope, token.Created, token.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	token.ID = int(id)
+
+	return token, nil
+}
+
+// GetByPlaintext returns the token matching the plaintext, or ErrNoRecord if there is no such token
+// or it has expired
+func (m *TokenModel) GetByPlaintext(plaintext string) (*Token, error) {
+	t := &Token{}
+
+	stmt := `SELECT id, hash, user_id, name, scope, created, expiry FROM tokens
+    WHERE hash = ? AND expiry > UTC_TIMESTAMP()`
+
+	err := m.DB.QueryRow(stmt, hashToken(plaintext)).Scan(&t.ID, &t.Hash, &t.UserID, &t.Name, &t.Scope, &t.Created, &t.Expiry)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ListByUser returns all the tokens of the user including the expired ones, the newest first
+func (m *TokenModel) ListByUser(userID int) ([]*Token, error) {
+	stmt := `SELECT id, user_id, name, scope, created, expiry FROM tokens
+    WHERE user_id = ? ORDER BY created DESC, id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*Token{}
+	for rows.Next() {
+		t := &Token{}
+		err = rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.Created, &t.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Delete revokes the token of the user, it returns ErrNoRecord if the user has no such token
+func (m *TokenModel) Delete(id, userID int) error {
+	stmt := "DELETE FROM tokens WHERE id = ? AND user_id = ?"
+
+	result, err := m.DB.Exec(stmt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"asniki/snippetbox/internal/assert"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenModelGetByPlaintext(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		token     string
+		wantScope string
+		wantErr   error
+	}{
+		{
+			name:      "Valid token",
+			ttl:       time.Hour,
+			wantScope: "read",
+		},
+		{
+			name:    "Expired token",
+			ttl:     -time.Hour,
+			wantErr: ErrNoRecord,
+		},
+		{
+			name:    "Unknown token",
+			ttl:     time.Hour,
+			token:   "UnknownToken",
+			wantErr: ErrNoRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := TokenModel{db}
+			token, err := m.New(1, "deploy script", "read", tt.ttl)
+			assert.NilError(t, err)
+
+			plaintext := token.Plaintext
+			if tt.token != "" {
+				plaintext = tt.token
+			}
+
+			got, err := m.GetByPlaintext(plaintext)
+
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+			if tt.wantErr == nil {
+				assert.Equal(t, got.ID, token.ID)
+				assert.Equal(t, got.UserID, 1)
+				assert.Equal(t, got.Scope, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestTokenModelDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := TokenModel{db}
+	token, err := m.New(1, "deploy script", "write", time.Hour)
+	assert.NilError(t, err)
+
+	tokens, err := m.ListByUser(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, tokens[0].Name, "deploy script")
+
+	err = m.Delete(token.ID, 2)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	err = m.Delete(token.ID, 1)
+	assert.NilError(t, err)
+
+	_, err = m.GetByPlaintext(token.Plaintext)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
@@ -12,11 +12,18 @@ import (
 
 // User holds the data for an individual user
 type User struct {
-	ID             int
-	Name           string
-	Email          string
-	HashedPassword []byte
-	Created        time.Time
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email,omitempty"`
+	HashedPassword []byte    `json:"-"`
+	Disabled       bool      `json:"-"`
+	Created        time.Time `json:"-"`
+}
+
+// UserStats holds the number of the users, in total and of the disabled ones
+type UserStats struct {
+	Total    int
+	Disabled int
 }
 
 // UserModelInterface describes the methods for the UserModel
@@ -26,6 +33,10 @@ type UserModelInterface interface {
 	Exists(id int) (bool, error)
 	Get(id int) (*User, error)
 	PasswordUpdate(id int, currentPassword, newPassword string) error
+	GetByEmail(email string) (*User, error)
+	PasswordReset(id int, newPassword string) error
+	SetDisabled(id int, disabled bool) error
+	Stats() (UserStats, error)
 }
 
 // UserModel wraps a database connection pool and provides methods to access and manipulate the users
@@ -58,14 +69,16 @@ func (m *UserModel) Insert(name, email, password string) error {
 }
 
 // Authenticate verifies whether a user exists with the provided email address and password
-// and returns the relevant user ID if user do exist
+// and returns the relevant user ID if user do exist. ErrAccountDisabled is only returned
+// for the correct password, so that it does not tell which accounts are disabled
 func (m *UserModel) Authenticate(email, password string) (int, error) {
 	var id int
 	var hashedPassword []byte
+	var disabled bool
 
-	stmt := "SELECT id, hashed_password FROM users WHERE email = ?"
+	stmt := "SELECT id, hashed_password, disabled FROM users WHERE email = ?"
 
-	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword)
+	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword, &disabled)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrInvalidCredentials
@@ -83,12 +96,17 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 		}
 	}
 
+	if disabled {
+		return 0, ErrAccountDisabled
+	}
+
 	return id, nil
 }
 
-// Exists checks if a user exists with a specific ID
+// Exists checks if an enabled user exists with a specific ID, so that disabling an account
+// also ends its sessions and stops its API tokens
 func (m *UserModel) Exists(id int) (bool, error) {
-	stmt := "SELECT EXISTS(SELECT true FROM users WHERE id = ?)"
+	stmt := "SELECT EXISTS(SELECT true FROM users WHERE id = ? AND NOT disabled)"
 
 	var exists bool
 	err := m.DB.QueryRow(stmt, id).Scan(&exists)
@@ -97,12 +115,22 @@ func (m *UserModel) Exists(id int) (bool, error) {
 
 // Get returns the user by id
 func (m *UserModel) Get(id int) (*User, error) {
-	stmt := "SELECT id, name, email, created FROM users WHERE id = ?"
+	return m.getBy("id", id)
+}
+
+// GetByEmail returns the user by email address
+func (m *UserModel) GetByEmail(email string) (*User, error) {
+	return m.getBy("email", email)
+}
+
+// getBy returns the user whose column equals the value, the column must be a trusted identifier
+func (m *UserModel) getBy(column string, value any) (*User, error) {
+	stmt := "SELECT id, name, email, disabled, created FROM users WHERE " + column + " = ?"
 
 	u := &User{}
 
-	row := m.DB.QueryRow(stmt, id)
-	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Created)
+	row := m.DB.QueryRow(stmt, value)
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Disabled, &u.Created)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -147,3 +175,52 @@ func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string)
 
 	return nil
 }
+
+// PasswordReset sets a new password for the user without checking the current one
+func (m *UserModel) PasswordReset(id int, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	stmt := "UPDATE users SET hashed_password = ? WHERE id = ?"
+
+	result, err := m.DB.Exec(stmt, string(hashedPassword), id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// SetDisabled disables or enables the account of the user
+func (m *UserModel) SetDisabled(id int, disabled bool) error {
+	var exists bool
+	err := m.DB.QueryRow("SELECT EXISTS(SELECT true FROM users WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNoRecord
+	}
+
+	_, err = m.DB.Exec("UPDATE users SET disabled = ? WHERE id = ?", disabled, id)
+	return err
+}
+
+// Stats returns the number of the users
+func (m *UserModel) Stats() (UserStats, error) {
+	stmt := "SELECT COUNT(*), COALESCE(SUM(disabled), 0) FROM users"
+
+	var stats UserStats
+	err := m.DB.QueryRow(stmt).Scan(&stats.Total, &stats.Disabled)
+	return stats, err
+}
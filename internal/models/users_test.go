@@ -26,10 +26,15 @@ func TestUserModelExists(t *testing.T) {
 			want:   false,
 		},
 		{
-			name:   "Non-existent ID",
+			name:   "Disabled user",
 			userID: 2,
 			want:   false,
 		},
+		{
+			name:   "Non-existent ID",
+			userID: 3,
+			want:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -16,6 +16,10 @@ type Validator struct {
 // EmailRX is a parsed regular expression pattern for sanity checking the format of an email address
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// TagRX is a parsed regular expression pattern for the snippet tags: lowercase letters and digits,
+// optionally separated by single dashes
+var TagRX = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
 // Valid returns true if the FieldErrors map doesn't contain any entries
 func (v *Validator) Valid() bool {
 	return len(v.FieldErrors) == 0 && len(v.NonFieldErrors) == 0
@@ -70,6 +74,11 @@ func Matches(value string, rx *regexp.Regexp) bool {
 	return rx.MatchString(value)
 }
 
+// MaxItems returns true if a list contains no more than n items
+func MaxItems[T any](values []T, n int) bool {
+	return len(values) <= n
+}
+
 // Equal checks equality of two values
 func Equal[T comparable](value, permittedValue T) bool {
 	return value == permittedValue
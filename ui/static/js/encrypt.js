@@ -0,0 +1,122 @@
+// Encrypts the snippet content in the browser before it is sent to the server and decrypts it when the
+// snippet is viewed. The AES-GCM key never reaches the server: it is kept in the URL fragment, which
+// browsers do not send along with the requests. The ciphertext is the base64 encoded IV followed by
+// the encrypted content
+
+var IV_LENGTH = 12;
+
+function toBase64(bytes) {
+	var binary = "";
+	for (var i = 0; i < bytes.length; i++) {
+		binary += String.fromCharCode(bytes[i]);
+	}
+	return btoa(binary);
+}
+
+function fromBase64(text) {
+	var binary = atob(text);
+	var bytes = new Uint8Array(binary.length);
+	for (var i = 0; i < binary.length; i++) {
+		bytes[i] = binary.charCodeAt(i);
+	}
+	return bytes;
+}
+
+function toBase64URL(bytes) {
+	return toBase64(bytes).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function fromBase64URL(text) {
+	return fromBase64(text.replace(/-/g, "+").replace(/_/g, "/"));
+}
+
+function keyFromFragment() {
+	var fragment = window.location.hash.substring(1);
+	if (fragment == "") {
+		return Promise.reject(new Error("the link is missing the key"));
+	}
+	return crypto.subtle.importKey("raw", fromBase64URL(fragment), "AES-GCM", false, ["decrypt"]);
+}
+
+function decrypt(ciphertext) {
+	return keyFromFragment().then(function (key) {
+		var payload = fromBase64(ciphertext);
+		return crypto.subtle.decrypt(
+			{name: "AES-GCM", iv: payload.slice(0, IV_LENGTH)},
+			key,
+			payload.slice(IV_LENGTH)
+		);
+	}).then(function (plaintext) {
+		return new TextDecoder().decode(plaintext);
+	});
+}
+
+function encrypt(plaintext) {
+	var iv = crypto.getRandomValues(new Uint8Array(IV_LENGTH));
+	var key;
+	return crypto.subtle.generateKey({name: "AES-GCM", length: 256}, true, ["encrypt", "decrypt"]).then(function (k) {
+		key = k;
+		return crypto.subtle.encrypt({name: "AES-GCM", iv: iv}, key, new TextEncoder().encode(plaintext));
+	}).then(function (encrypted) {
+		encrypted = new Uint8Array(encrypted);
+		var payload = new Uint8Array(IV_LENGTH + encrypted.length);
+		payload.set(iv);
+		payload.set(encrypted, IV_LENGTH);
+		return crypto.subtle.exportKey("raw", key).then(function (rawKey) {
+			return {ciphertext: toBase64(payload), key: toBase64URL(new Uint8Array(rawKey))};
+		});
+	});
+}
+
+// the create form: encrypt the content on submit
+var encryptedCheckbox = document.querySelector("input[name='encrypted']");
+if (encryptedCheckbox) {
+	var form = encryptedCheckbox.form;
+	var content = form.querySelector("textarea[name='content']");
+
+	// the form came back with validation errors, the key is in the fragment of the form action
+	// the content was posted to, so give the user their plaintext back
+	if (encryptedCheckbox.checked && window.location.hash != "") {
+		decrypt(content.value).then(function (plaintext) {
+			content.value = plaintext;
+		}).catch(function () {});
+	}
+
+	form.addEventListener("submit", function (event) {
+		if (!encryptedCheckbox.checked || form.dataset.encrypted == "true") {
+			return;
+		}
+		event.preventDefault();
+
+		encrypt(content.value).then(function (result) {
+			content.value = result.ciphertext;
+			// the redirect to the new snippet keeps the fragment of the URL the form was posted to
+			form.action = form.getAttribute("action").split("#")[0] + "#" + result.key;
+			form.dataset.encrypted = "true";
+			form.submit();
+		}).catch(function (err) {
+			alert("The snippet could not be encrypted: " + err.message);
+		});
+	});
+}
+
+// the view page: decrypt the content and keep the key on the links to the snippet
+var encryptedContent = document.getElementById("encrypted-content");
+if (encryptedContent) {
+	decrypt(encryptedContent.dataset.ciphertext).then(function (plaintext) {
+		encryptedContent.textContent = plaintext;
+	}).catch(function (err) {
+		encryptedContent.textContent = "This snippet is encrypted and cannot be decrypted: " + err.message + ".";
+	});
+
+	var shareLinks = document.querySelectorAll("a[data-keep-fragment]");
+	for (var i = 0; i < shareLinks.length; i++) {
+		shareLinks[i].href = shareLinks[i].getAttribute("href") + window.location.hash;
+	}
+}
+
+// the reveal page of a burn-after-reading snippet: keep the key on the form revealing it
+var revealForms = document.querySelectorAll("form[data-keep-fragment]");
+for (var i = 0; i < revealForms.length; i++) {
+	revealForms[i].action = revealForms[i].getAttribute("action") + window.location.hash;
+}
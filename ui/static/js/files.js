@@ -0,0 +1,32 @@
+// Adds and removes the additional files on the "snippet create" form. Every new file is numbered
+// after the last one, the server skips the gaps in the numbering left by the removed files
+
+var fileList = document.getElementById("files");
+if (fileList) {
+	var fileTemplate = document.getElementById("file-template");
+	var nextFileIndex = fileList.querySelectorAll(".file").length;
+
+	function handleRemoveFile(row) {
+		row.querySelector(".remove-file").addEventListener("click", function () {
+			row.remove();
+		});
+	}
+
+	var fileRows = fileList.querySelectorAll(".file");
+	for (var i = 0; i < fileRows.length; i++) {
+		handleRemoveFile(fileRows[i]);
+	}
+
+	document.getElementById("add-file").addEventListener("click", function () {
+		var row = fileTemplate.content.firstElementChild.cloneNode(true);
+		var fields = row.querySelectorAll("[data-field]");
+		for (var i = 0; i < fields.length; i++) {
+			fields[i].name = "files[" + nextFileIndex + "]." + fields[i].dataset.field;
+		}
+		nextFileIndex++;
+
+		handleRemoveFile(row);
+		fileList.appendChild(row);
+		row.querySelector("input").focus();
+	});
+}